@@ -9,12 +9,30 @@ import (
 func main() {
 	root := cmd.NewRootCommand()
 
+	initCommand := cmd.NewInitCommand()
+	root.AddCommand(initCommand)
+
 	commit := cmd.NewCommitCommand()
 	root.AddCommand(commit)
 
 	branch := cmd.NewBranchCommand()
 	root.AddCommand(branch)
 
+	reword := cmd.NewRewordCommand()
+	root.AddCommand(reword)
+
+	squashMsg := cmd.NewSquashMsgCommand()
+	root.AddCommand(squashMsg)
+
+	cache := cmd.NewCacheCommand()
+	root.AddCommand(cache)
+
+	prompt := cmd.NewPromptCommand()
+	root.AddCommand(prompt)
+
+	usage := cmd.NewUsageCommand()
+	root.AddCommand(usage)
+
 	version := cmd.NewVersionCommand()
 	root.AddCommand(version)
 
@@ -2,26 +2,154 @@ package internal
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
 
 	"github.com/sashabaranov/go-openai"
+
+	"github.com/tolgaOzen/combo/pkg/cache"
 )
 
 // OpenAIClient wraps the OpenAI client
 type OpenAIClient struct {
-	client *openai.Client
+	client   *openai.Client
+	recorder *retryAfterRecorder
+	retry    RetryPolicy
+	cache    *cache.Cache
+	usage    UsageTracker
+	azure    *AzureConfig
+}
+
+// AzureConfig points the client at an Azure OpenAI resource.
+type AzureConfig struct {
+	Endpoint   string // Resource endpoint, e.g. https://my-resource.openai.azure.com
+	Deployment string // Deployment serving every request; empty derives it from the model name.
+	APIVersion string // REST API version, e.g. 2024-10-21.
+	Bearer     bool   // Send the key as a Microsoft Entra ID bearer token instead of an api-key header.
+}
+
+// UsageTracker records the token usage of requests and enforces spending limits.
+type UsageTracker interface {
+	// Allow returns an error when no further requests may be made to the model.
+	Allow(model string) error
+	// Record stores the token usage of a completed request.
+	Record(model string, promptTokens, completionTokens int) error
+}
+
+// ClientOption defines a functional option for configuring the OpenAIClient.
+type ClientOption func(*OpenAIClient)
+
+// WithRetryPolicy sets how failed requests are retried.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(o *OpenAIClient) {
+		o.retry = policy
+	}
+}
+
+// WithCache serves repeated requests from the given response cache.
+func WithCache(c *cache.Cache) ClientOption {
+	return func(o *OpenAIClient) {
+		o.cache = c
+	}
+}
+
+// WithUsageTracker records the token usage of every request that reaches the API.
+func WithUsageTracker(t UsageTracker) ClientOption {
+	return func(o *OpenAIClient) {
+		o.usage = t
+	}
+}
+
+// WithAzure sends requests to an Azure OpenAI deployment instead of the OpenAI API.
+func WithAzure(azure AzureConfig) ClientOption {
+	return func(o *OpenAIClient) {
+		o.azure = &azure
+	}
 }
 
 // NewOpenAIClient initializes a new OpenAIClient
-func NewOpenAIClient(apiKey string) *OpenAIClient {
-	return &OpenAIClient{
-		client: openai.NewClient(apiKey),
+func NewOpenAIClient(apiKey string, opts ...ClientOption) *OpenAIClient {
+	o := &OpenAIClient{
+		recorder: &retryAfterRecorder{client: &http.Client{}},
+		retry:    DefaultRetryPolicy,
+	}
+
+	// Apply functional options
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	config := openai.DefaultConfig(apiKey)
+	if o.azure != nil {
+		config = azureClientConfig(apiKey, *o.azure)
+	}
+	config.HTTPClient = o.recorder
+	o.client = openai.NewClientWithConfig(config)
+
+	return o
+}
+
+// azureClientConfig builds the go-openai configuration for an Azure deployment.
+func azureClientConfig(apiKey string, azure AzureConfig) openai.ClientConfig {
+	config := openai.DefaultAzureConfig(apiKey, azure.Endpoint)
+	if azure.Bearer {
+		config.APIType = openai.APITypeAzureAD
+	}
+	if azure.APIVersion != "" {
+		config.APIVersion = azure.APIVersion
+	}
+	if azure.Deployment != "" {
+		config.AzureModelMapperFunc = func(string) string {
+			return azure.Deployment
+		}
+	}
+	return config
+}
+
+// RequestOption defines a functional option for configuring a ChatCompletionRequest.
+type RequestOption func(*openai.ChatCompletionRequest)
+
+// WithJSONMode asks the model to answer with a JSON object. The prompt must
+// mention JSON and describe the expected fields.
+func WithJSONMode() RequestOption {
+	return func(r *openai.ChatCompletionRequest) {
+		r.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		}
+	}
+}
+
+// WithJSONSchema constrains the answer to a JSON object matching a strict schema.
+func WithJSONSchema(name string, schema json.Marshaler) RequestOption {
+	return func(r *openai.ChatCompletionRequest) {
+		r.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   name,
+				Schema: schema,
+				Strict: true,
+			},
+		}
+	}
+}
+
+// DefaultMaxTokens caps the answer to a request, which is enough for a commit
+// subject and a short body.
+const DefaultMaxTokens = 200
+
+// WithMaxTokens sets the most tokens the model may answer with.
+func WithMaxTokens(n int) RequestOption {
+	return func(r *openai.ChatCompletionRequest) {
+		r.MaxTokens = n
 	}
 }
 
 // CreateChatCompletionRequest constructs the ChatCompletionRequest
-func CreateChatCompletionRequest(prompt, diff string) openai.ChatCompletionRequest {
-	return openai.ChatCompletionRequest{
-		Model: openai.GPT3Dot5Turbo,
+func CreateChatCompletionRequest(model, prompt, diff string, opts ...RequestOption) openai.ChatCompletionRequest {
+	request := openai.ChatCompletionRequest{
+		Model: model,
 		Messages: []openai.ChatCompletionMessage{
 			{
 				Role:    openai.ChatMessageRoleSystem,
@@ -36,13 +164,77 @@ func CreateChatCompletionRequest(prompt, diff string) openai.ChatCompletionReque
 		TopP:             1,
 		FrequencyPenalty: 0,
 		PresencePenalty:  0,
-		MaxTokens:        200,
+		MaxTokens:        DefaultMaxTokens,
 		N:                1,
 		Stream:           false,
 	}
+
+	// Apply functional options
+	for _, opt := range opts {
+		opt(&request)
+	}
+
+	return request
 }
 
-// SendChatCompletionRequest sends the request and returns the response
+// SendChatCompletionRequest sends the request and returns the response.
+// Identical requests are answered from the cache when one is configured;
+// other requests are checked against, and recorded by, the usage tracker.
+// Rate limits, server errors and network failures are retried according to
+// the client's RetryPolicy, honouring Retry-After and the deadline of ctx.
 func (o *OpenAIClient) SendChatCompletionRequest(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
-	return o.client.CreateChatCompletion(ctx, request)
+	key, err := o.cacheKey(request)
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+
+	var response openai.ChatCompletionResponse
+	if o.cache.Get(key, &response) {
+		return response, nil
+	}
+
+	if o.usage != nil {
+		if err := o.usage.Allow(request.Model); err != nil {
+			return response, err
+		}
+	}
+
+	response, err = retry(ctx, o.retry, o.recorder.RetryAfter, func(ctx context.Context) (openai.ChatCompletionResponse, error) {
+		return o.client.CreateChatCompletion(ctx, request)
+	})
+	if err != nil {
+		return response, err
+	}
+
+	// As with the cache, a response that was paid for is kept even if it cannot be recorded
+	if o.usage != nil {
+		model := response.Model
+		if model == "" {
+			model = request.Model
+		}
+		if err := o.usage.Record(model, response.Usage.PromptTokens, response.Usage.CompletionTokens); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+
+	// Only complete answers are worth keeping; the cache is best effort, so a
+	// failure to store one must not throw away a response that was paid for
+	if len(response.Choices) > 0 && response.Choices[0].Message.Content != "" {
+		_ = o.cache.Put(key, response)
+	}
+
+	return response, nil
+}
+
+// cacheKey identifies a request. Azure requests also include the endpoint and
+// deployment, since the same model name may be served by different deployments.
+func (o *OpenAIClient) cacheKey(request openai.ChatCompletionRequest) (string, error) {
+	if o.azure == nil {
+		return cache.Key(request)
+	}
+	return cache.Key(struct {
+		Endpoint   string                       `json:"endpoint"`
+		Deployment string                       `json:"deployment"`
+		Request    openai.ChatCompletionRequest `json:"request"`
+	}{o.azure.Endpoint, o.azure.Deployment, request})
 }
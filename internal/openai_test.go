@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// azureServer mimics the chat completions endpoint of an Azure OpenAI
+// resource, reporting each request it receives.
+func azureServer(t *testing.T, requests chan<- *http.Request) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r.Clone(context.Background())
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":      "chatcmpl-1",
+			"object":  "chat.completion",
+			"model":   "gpt-4o-mini",
+			"choices": []map[string]any{{"index": 0, "message": map[string]string{"role": "assistant", "content": "feat: add login"}}},
+			"usage":   map[string]int{"prompt_tokens": 10, "completion_tokens": 3, "total_tokens": 13},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAzureRequests(t *testing.T) {
+	tests := []struct {
+		name           string
+		azure          AzureConfig
+		model          string
+		wantPath       string
+		wantAPIKey     string
+		wantBearer     string
+		wantAPIVersion string
+	}{
+		{
+			name:           "api-key with a deployment",
+			azure:          AzureConfig{Deployment: "commit-writer", APIVersion: "2024-10-21"},
+			model:          "gpt-4o-mini",
+			wantPath:       "/openai/deployments/commit-writer/chat/completions",
+			wantAPIKey:     "secret",
+			wantAPIVersion: "2024-10-21",
+		},
+		{
+			name:           "deployment derived from the model",
+			azure:          AzureConfig{APIVersion: "2024-10-21"},
+			model:          "gpt-3.5-turbo",
+			wantPath:       "/openai/deployments/gpt-35-turbo/chat/completions",
+			wantAPIKey:     "secret",
+			wantAPIVersion: "2024-10-21",
+		},
+		{
+			name:           "bearer token",
+			azure:          AzureConfig{Deployment: "commit-writer", APIVersion: "2025-01-01-preview", Bearer: true},
+			model:          "gpt-4o-mini",
+			wantPath:       "/openai/deployments/commit-writer/chat/completions",
+			wantBearer:     "Bearer secret",
+			wantAPIVersion: "2025-01-01-preview",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := make(chan *http.Request, 1)
+			tt.azure.Endpoint = azureServer(t, requests).URL
+
+			client := NewOpenAIClient("secret", WithAzure(tt.azure), WithRetryPolicy(RetryPolicy{}))
+			response, err := client.SendChatCompletionRequest(context.Background(), CreateChatCompletionRequest(tt.model, "prompt", "diff"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := response.Choices[0].Message.Content; got != "feat: add login" {
+				t.Errorf("content = %q", got)
+			}
+
+			r := <-requests
+			if r.URL.Path != tt.wantPath {
+				t.Errorf("path = %q, want %q", r.URL.Path, tt.wantPath)
+			}
+			if got := r.URL.Query().Get("api-version"); got != tt.wantAPIVersion {
+				t.Errorf("api-version = %q, want %q", got, tt.wantAPIVersion)
+			}
+			if got := r.Header.Get("api-key"); got != tt.wantAPIKey {
+				t.Errorf("api-key header = %q, want %q", got, tt.wantAPIKey)
+			}
+			if got := r.Header.Get("Authorization"); got != tt.wantBearer {
+				t.Errorf("Authorization header = %q, want %q", got, tt.wantBearer)
+			}
+		})
+	}
+}
+
+func TestAzureJSONSchemaRequest(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{}"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient("secret", WithAzure(AzureConfig{Endpoint: server.URL, Deployment: "d", APIVersion: "2024-10-21"}), WithRetryPolicy(RetryPolicy{}))
+	request := CreateChatCompletionRequest("gpt-4o-mini", "Answer in JSON.", "diff", WithJSONSchema("commit_message", json.RawMessage(`{"type":"object"}`)))
+	if _, err := client.SendChatCompletionRequest(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+
+	format, _ := body["response_format"].(map[string]any)
+	if format["type"] != "json_schema" {
+		t.Errorf("response_format = %v, want a json_schema format", body["response_format"])
+	}
+}
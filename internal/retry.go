@@ -0,0 +1,208 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// RetryPolicy controls how failed requests are retried.
+type RetryPolicy struct {
+	MaxRetries int           // Number of retries after the first attempt.
+	BaseDelay  time.Duration // Delay before the first retry; doubled for each further retry.
+	MaxDelay   time.Duration // Upper bound of a single delay.
+	Timeout    time.Duration // Time limit of a single attempt; zero means none.
+}
+
+// DefaultRetryPolicy retries three times, starting at one second, and gives
+// each attempt thirty seconds.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  time.Second,
+	MaxDelay:   30 * time.Second,
+	Timeout:    30 * time.Second,
+}
+
+// RetryEvent describes a retry that is about to happen.
+type RetryEvent struct {
+	Attempt    int           // Number of the upcoming retry, starting at 1.
+	MaxRetries int           // Total number of retries allowed.
+	Delay      time.Duration // Time waited before the retry.
+	Err        error         // Error of the failed attempt.
+}
+
+type retryObserverKey struct{}
+
+// WithRetryObserver returns a context that reports every retry of requests made with it.
+func WithRetryObserver(ctx context.Context, observe func(RetryEvent)) context.Context {
+	return context.WithValue(ctx, retryObserverKey{}, observe)
+}
+
+// observeRetry reports a retry to the observer attached to ctx, if any.
+func observeRetry(ctx context.Context, event RetryEvent) {
+	if observe, ok := ctx.Value(retryObserverKey{}).(func(RetryEvent)); ok {
+		observe(event)
+	}
+}
+
+// retry runs fn until it succeeds, fails with an error that is not worth
+// retrying, runs out of retries, or the next delay would pass the deadline of
+// ctx. Each attempt is limited to the policy's timeout, and one that runs out
+// of it is retried like any other transient failure.
+func retry[T any](ctx context.Context, policy RetryPolicy, retryAfter func() time.Duration, fn func(ctx context.Context) (T, error)) (T, error) {
+	for attempt := 0; ; attempt++ {
+		result, timedOut, err := try(ctx, policy.Timeout, fn)
+		if err == nil || attempt >= policy.MaxRetries || !(timedOut || isRetryable(ctx, err)) {
+			return result, err
+		}
+
+		delay := retryAfter()
+		if delay <= 0 {
+			delay = backoff(policy, attempt)
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return result, err
+		}
+
+		observeRetry(ctx, RetryEvent{
+			Attempt:    attempt + 1,
+			MaxRetries: policy.MaxRetries,
+			Delay:      delay,
+			Err:        err,
+		})
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+	}
+}
+
+// try runs a single attempt of fn, limited to timeout if it is positive, and
+// reports whether the attempt ran out of time while ctx itself did not.
+func try[T any](ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (T, error)) (T, bool, error) {
+	if timeout <= 0 {
+		result, err := fn(ctx)
+		return result, false, err
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result, err := fn(attemptCtx)
+	timedOut := err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded)
+	return result, timedOut, err
+}
+
+// backoff returns an exponential delay with full jitter for the given attempt.
+func backoff(policy RetryPolicy, attempt int) time.Duration {
+	ceiling := policy.BaseDelay << attempt
+	if ceiling <= 0 || (policy.MaxDelay > 0 && ceiling > policy.MaxDelay) {
+		ceiling = policy.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	// Keep at least half the delay so retries never fire back to back
+	return ceiling/2 + rand.N(ceiling/2+1)
+}
+
+// retryableStatus lists the HTTP status codes worth retrying.
+var retryableStatus = map[int]bool{
+	http.StatusRequestTimeout:      true,
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// isRetryable reports whether err is a transient failure: a rate limit, a
+// server error or a network failure. Exhausted quotas, client errors and
+// cancellations are final.
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		if code, ok := apiErr.Code.(string); ok && code == "insufficient_quota" {
+			return false
+		}
+		return retryableStatus[apiErr.HTTPStatusCode]
+	}
+
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return retryableStatus[reqErr.HTTPStatusCode]
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// retryAfterRecorder is an HTTP client that remembers the Retry-After header
+// of the last response, which go-openai does not expose on errors.
+type retryAfterRecorder struct {
+	client *http.Client
+
+	mu    sync.Mutex
+	delay time.Duration
+}
+
+// Do sends the request and records the server's requested retry delay.
+func (r *retryAfterRecorder) Do(req *http.Request) (*http.Response, error) {
+	resp, err := r.client.Do(req)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.delay = 0
+	if resp != nil {
+		r.delay = parseRetryAfter(resp.Header)
+	}
+
+	return resp, err
+}
+
+// RetryAfter returns the delay requested by the last response, or zero.
+func (r *retryAfterRecorder) RetryAfter() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.delay
+}
+
+// parseRetryAfter reads retry-after-ms or Retry-After, in seconds or as an HTTP date.
+func parseRetryAfter(header http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
@@ -0,0 +1,228 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// rateLimitedServer answers the first limited requests with 429 and the given
+// Retry-After header, and the rest with a completion. It counts the requests.
+func rateLimitedServer(t *testing.T, limited int32, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if requests.Add(1) <= limited {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusTooManyRequests)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]string{"message": "Rate limit reached", "type": "requests", "code": "rate_limit_exceeded"},
+			})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": "feat: add login"}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// rateLimitedClient returns a client of the server whose own backoff is far
+// longer than the test, so only an honoured Retry-After lets it retry in time.
+func rateLimitedClient(server *httptest.Server, maxRetries int) *OpenAIClient {
+	return NewOpenAIClient("secret",
+		WithAzure(AzureConfig{Endpoint: server.URL, Deployment: "d", APIVersion: "2024-10-21"}),
+		WithRetryPolicy(RetryPolicy{MaxRetries: maxRetries, BaseDelay: time.Hour, MaxDelay: time.Hour}))
+}
+
+func TestRetryAfterIsHonoured(t *testing.T) {
+	server, requests := rateLimitedServer(t, 2, "0.05")
+	client := rateLimitedClient(server, 3)
+
+	var delays []time.Duration
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx = WithRetryObserver(ctx, func(event RetryEvent) { delays = append(delays, event.Delay) })
+
+	response, err := client.SendChatCompletionRequest(ctx, CreateChatCompletionRequest("gpt-4o-mini", "prompt", "diff"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := response.Choices[0].Message.Content; got != "feat: add login" {
+		t.Errorf("content = %q", got)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("server got %d requests, want 3", got)
+	}
+	want := []time.Duration{50 * time.Millisecond, 50 * time.Millisecond}
+	if fmt.Sprint(delays) != fmt.Sprint(want) {
+		t.Errorf("retry delays = %v, want %v", delays, want)
+	}
+}
+
+func TestRetriesStopAfterMaxRetries(t *testing.T) {
+	server, requests := rateLimitedServer(t, 100, "0.01")
+	client := rateLimitedClient(server, 2)
+
+	_, err := client.SendChatCompletionRequest(context.Background(), CreateChatCompletionRequest("gpt-4o-mini", "prompt", "diff"))
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusTooManyRequests {
+		t.Fatalf("err = %v, want the rate limit error", err)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("server got %d requests, want the first attempt and 2 retries", got)
+	}
+}
+
+func TestRetryAfterPastDeadline(t *testing.T) {
+	server, requests := rateLimitedServer(t, 100, "60")
+	client := rateLimitedClient(server, 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.SendChatCompletionRequest(ctx, CreateChatCompletionRequest("gpt-4o-mini", "prompt", "diff")); err == nil {
+		t.Fatal("request succeeded")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("server got %d requests, want 1: the requested delay passes the deadline", got)
+	}
+}
+
+func TestAttemptTimeoutIsRetried(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first attempt stalls until the client gives up on it
+		if requests.Add(1) == 1 {
+			// Reading the body lets the server notice the client hanging up
+			_, _ = io.Copy(io.Discard, r.Body)
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": "feat: add login"}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewOpenAIClient("secret",
+		WithAzure(AzureConfig{Endpoint: server.URL, Deployment: "d", APIVersion: "2024-10-21"}),
+		WithRetryPolicy(RetryPolicy{MaxRetries: 1, BaseDelay: time.Millisecond, Timeout: 100 * time.Millisecond}))
+
+	response, err := client.SendChatCompletionRequest(context.Background(), CreateChatCompletionRequest("gpt-4o-mini", "prompt", "diff"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := response.Choices[0].Message.Content; got != "feat: add login" {
+		t.Errorf("content = %q", got)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("server got %d requests, want 2", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{name: "none", header: http.Header{}},
+		{name: "seconds", header: http.Header{"Retry-After": {"3"}}, want: 3 * time.Second},
+		{name: "fractional seconds", header: http.Header{"Retry-After": {"0.5"}}, want: 500 * time.Millisecond},
+		{name: "milliseconds win", header: http.Header{"Retry-After": {"3"}, "Retry-After-Ms": {"250"}}, want: 250 * time.Millisecond},
+		{name: "zero", header: http.Header{"Retry-After": {"0"}}},
+		{name: "negative", header: http.Header{"Retry-After": {"-1"}}},
+		{name: "invalid", header: http.Header{"Retry-After": {"soon"}}},
+		{name: "past date", header: http.Header{"Retry-After": {"Wed, 21 Oct 2015 07:28:00 GMT"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseRetryAfter(tt.header)
+			if tt.want == 0 && got > 0 || tt.want != 0 && got != tt.want {
+				t.Errorf("parseRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(http.Header{"Retry-After": {date}}); got <= 58*time.Second || got > time.Minute {
+		t.Errorf("parseRetryAfter(%s) = %v, want about a minute", date, got)
+	}
+}
+
+// timeoutError is a network timeout.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ net.Error = timeoutError{}
+
+func TestIsRetryable(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{name: "rate limit", err: &openai.APIError{HTTPStatusCode: 429}, want: true},
+		{name: "server error", err: fmt.Errorf("wrapped: %w", &openai.APIError{HTTPStatusCode: 503}), want: true},
+		{name: "exhausted quota", err: &openai.APIError{HTTPStatusCode: 429, Code: "insufficient_quota"}},
+		{name: "bad request", err: &openai.APIError{HTTPStatusCode: 400}},
+		{name: "unauthorized", err: &openai.APIError{HTTPStatusCode: 401}},
+		{name: "request error", err: &openai.RequestError{HTTPStatusCode: 502}, want: true},
+		{name: "request client error", err: &openai.RequestError{HTTPStatusCode: 404}},
+		{name: "network timeout", err: timeoutError{}, want: true},
+		{name: "unexpected EOF", err: io.ErrUnexpectedEOF, want: true},
+		{name: "other error", err: errors.New("boom")},
+		{name: "deadline", err: context.DeadlineExceeded},
+		{name: "canceled context", ctx: canceled, err: &openai.APIError{HTTPStatusCode: 429}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			if got := isRetryable(ctx, tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	for attempt, ceiling := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		for i := 0; i < 50; i++ {
+			if got := backoff(policy, attempt); got < ceiling/2 || got > ceiling {
+				t.Fatalf("backoff(attempt %d) = %v, want between %v and %v", attempt, got, ceiling/2, ceiling)
+			}
+		}
+	}
+
+	if got := backoff(RetryPolicy{}, 2); got != 0 {
+		t.Errorf("backoff of an empty policy = %v, want 0", got)
+	}
+	// A shift past the size of a duration falls back to the maximum
+	if got := backoff(policy, 80); got < policy.MaxDelay/2 || got > policy.MaxDelay {
+		t.Errorf("backoff(attempt 80) = %v", got)
+	}
+}
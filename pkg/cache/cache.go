@@ -0,0 +1,191 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// entryExt is the file extension of cache entries.
+const entryExt = ".json"
+
+// Cache stores generated responses on disk, keyed by a hash of the request.
+// Entries expire after the TTL, and the least recently used ones are evicted
+// once the total size exceeds the limit.
+type Cache struct {
+	dir     string
+	ttl     time.Duration
+	maxSize int64
+	now     func() time.Time
+}
+
+// entry is the on-disk format of a cached response.
+type entry struct {
+	Created time.Time       `json:"created"`
+	Value   json.RawMessage `json:"value"`
+}
+
+// New returns a cache in dir. A non-positive TTL disables the cache.
+func New(dir string, ttl time.Duration, maxSize int64) *Cache {
+	return &Cache{dir: dir, ttl: ttl, maxSize: maxSize, now: time.Now}
+}
+
+// DefaultDir returns the cache directory, ~/.combo/cache.
+func DefaultDir() (string, error) {
+	dir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(dir, ".combo", "cache"), nil
+}
+
+// Key hashes any JSON-encodable request into a cache key.
+func Key(request any) (string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cache key: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Get loads the value stored under key into v, reporting whether a fresh entry was found.
+func (c *Cache) Get(key string, v any) bool {
+	if c == nil || c.ttl <= 0 {
+		return false
+	}
+
+	path := c.path(key)
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil || c.now().Sub(e.Created) > c.ttl {
+		_ = os.Remove(path)
+		return false
+	}
+	if err := json.Unmarshal(e.Value, v); err != nil {
+		return false
+	}
+
+	// Mark the entry as recently used
+	now := c.now()
+	_ = os.Chtimes(path, now, now)
+	return true
+}
+
+// Put stores v under key and evicts old entries if the cache grew too large.
+func (c *Cache) Put(key string, v any) error {
+	if c == nil || c.ttl <= 0 {
+		return nil
+	}
+
+	value, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	now := c.now()
+	data, err := json.Marshal(entry{Created: now, Value: value})
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.WriteFile(c.path(key), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	_ = os.Chtimes(c.path(key), now, now)
+
+	return c.prune()
+}
+
+// Clear removes every entry, returning how many were removed.
+func (c *Cache) Clear() (int, error) {
+	files, err := c.entries()
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range files {
+		if err := os.Remove(filepath.Join(c.dir, f.Name())); err != nil && !os.IsNotExist(err) {
+			return 0, fmt.Errorf("failed to remove cache entry: %w", err)
+		}
+	}
+	return len(files), nil
+}
+
+// prune removes expired entries, then the least recently used ones until the
+// cache fits its size limit. Entries expire by their creation time, as in Get;
+// the modification time only records when they were last used.
+func (c *Cache) prune() error {
+	files, err := c.entries()
+	if err != nil {
+		return err
+	}
+
+	// Most recently used first
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime().After(files[j].ModTime()) })
+
+	var total int64
+	for _, f := range files {
+		total += f.Size()
+		expired := c.expired(filepath.Join(c.dir, f.Name()))
+		if expired || (c.maxSize > 0 && total > c.maxSize) {
+			if err := os.Remove(filepath.Join(c.dir, f.Name())); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to evict cache entry: %w", err)
+			}
+			total -= f.Size()
+		}
+	}
+
+	return nil
+}
+
+// expired reports whether the entry at path has outlived the TTL. Entries
+// that cannot be read count as expired, as Get would discard them too.
+func (c *Cache) expired(path string) bool {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return true
+	}
+	var e entry
+	return json.Unmarshal(data, &e) != nil || c.now().Sub(e.Created) > c.ttl
+}
+
+// entries lists the cache entry files.
+func (c *Cache) entries() ([]os.FileInfo, error) {
+	dirEntries, err := os.ReadDir(c.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var files []os.FileInfo
+	for _, d := range dirEntries {
+		if d.IsDir() || !strings.HasSuffix(d.Name(), entryExt) {
+			continue
+		}
+		info, err := d.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, info)
+	}
+	return files, nil
+}
+
+// path returns the file of a cache entry.
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+entryExt)
+}
@@ -0,0 +1,181 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCache returns a cache in a temporary directory whose clock is moved
+// by advancing the returned time.
+func testCache(t *testing.T, ttl time.Duration, maxSize int64) (*Cache, *time.Time) {
+	t.Helper()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	c := New(t.TempDir(), ttl, maxSize)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func TestGetPut(t *testing.T) {
+	c, _ := testCache(t, time.Hour, 0)
+
+	var got string
+	if c.Get("a", &got) {
+		t.Fatal("Get found an entry in an empty cache")
+	}
+	if err := c.Put("a", "feat: add login"); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Get("a", &got) || got != "feat: add login" {
+		t.Errorf("Get() = %q", got)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	c, now := testCache(t, time.Hour, 0)
+	if err := c.Put("a", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+	*now = now.Add(59 * time.Minute)
+	if !c.Get("a", &got) {
+		t.Fatal("entry expired before its TTL")
+	}
+
+	// Reading an entry does not extend its lifetime
+	*now = now.Add(2 * time.Minute)
+	if c.Get("a", &got) {
+		t.Fatal("entry outlived its TTL")
+	}
+	if _, err := os.Stat(c.path("a")); !os.IsNotExist(err) {
+		t.Errorf("expired entry was not removed: %v", err)
+	}
+}
+
+func TestPutPrunesExpired(t *testing.T) {
+	c, now := testCache(t, time.Hour, 0)
+	if err := c.Put("old", "value"); err != nil {
+		t.Fatal(err)
+	}
+	*now = now.Add(2 * time.Hour)
+	if err := c.Put("new", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(c.path("old")); !os.IsNotExist(err) {
+		t.Errorf("expired entry survived a Put: %v", err)
+	}
+}
+
+func TestPutPrunesByCreation(t *testing.T) {
+	c, now := testCache(t, time.Hour, 0)
+	if err := c.Put("old", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A read just before expiry marks the entry as used without extending its lifetime
+	var got string
+	*now = now.Add(59 * time.Minute)
+	if !c.Get("old", &got) {
+		t.Fatal("entry expired before its TTL")
+	}
+	*now = now.Add(2 * time.Minute)
+	if err := c.Put("new", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(c.path("old")); !os.IsNotExist(err) {
+		t.Errorf("entry created past its TTL survived a Put: %v", err)
+	}
+}
+
+func TestSizeEviction(t *testing.T) {
+	c, now := testCache(t, time.Hour, 0)
+	if err := c.Put("a", "value"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(c.path("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Room for three entries of the same size
+	c.maxSize = 3*info.Size() + info.Size()/2
+
+	for _, key := range []string{"b", "c"} {
+		*now = now.Add(time.Minute)
+		if err := c.Put(key, "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Using a makes b the least recently used entry
+	*now = now.Add(time.Minute)
+	var got string
+	if !c.Get("a", &got) {
+		t.Fatal("entry a was evicted early")
+	}
+	*now = now.Add(time.Minute)
+	if err := c.Put("d", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true, "d": true} {
+		_, err := os.Stat(c.path(key))
+		if exists := err == nil; exists != want {
+			t.Errorf("entry %s exists = %v, want %v", key, exists, want)
+		}
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	c, _ := testCache(t, 0, 0)
+	if err := c.Put("a", "value"); err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	if c.Get("a", &got) {
+		t.Error("a disabled cache returned an entry")
+	}
+	if entries, _ := os.ReadDir(c.dir); len(entries) != 0 {
+		t.Errorf("a disabled cache wrote %d entries", len(entries))
+	}
+
+	var nilCache *Cache
+	if nilCache.Get("a", &got) || nilCache.Put("a", "value") != nil {
+		t.Error("a nil cache is not a no-op")
+	}
+}
+
+func TestClear(t *testing.T) {
+	c, _ := testCache(t, time.Hour, 0)
+	for _, key := range []string{"a", "b"} {
+		if err := c.Put(key, "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Files that are not entries are left alone
+	other := filepath.Join(c.dir, "notes.txt")
+	if err := os.WriteFile(other, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := c.Clear()
+	if err != nil || n != 2 {
+		t.Errorf("Clear() = %d, %v, want 2", n, err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("Clear removed another file: %v", err)
+	}
+}
+
+func TestKey(t *testing.T) {
+	a, err := Key(map[string]string{"model": "gpt-4o", "prompt": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := Key(map[string]string{"prompt": "x", "model": "gpt-4o"})
+	c, _ := Key(map[string]string{"model": "gpt-4o", "prompt": "y"})
+	if a != b || a == c || len(a) != 64 {
+		t.Errorf("Key() = %s, %s, %s", a, b, c)
+	}
+}
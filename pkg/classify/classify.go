@@ -0,0 +1,201 @@
+package classify
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule maps files matching any of its glob patterns to a commit type.
+//
+// A pattern without a slash matches the file name in any directory, e.g.
+// "*.md". A pattern with a slash matches the path from the repository root,
+// where "**" stands for any number of directories, e.g. "docs/**" or
+// "**/__tests__/**".
+type Rule struct {
+	Type     string
+	Patterns []string
+
+	// compiled holds the regular expressions of Patterns, in order, for rules
+	// made by ParseRules and DefaultRules.
+	compiled []*regexp.Regexp
+}
+
+// compile returns the rule with its patterns compiled.
+func (r Rule) compile() (Rule, error) {
+	r.compiled = make([]*regexp.Regexp, len(r.Patterns))
+	for i, pattern := range r.Patterns {
+		re, err := compile(pattern)
+		if err != nil {
+			return r, fmt.Errorf("invalid pattern %q in path rule: %w", pattern, err)
+		}
+		r.compiled[i] = re
+	}
+	return r, nil
+}
+
+// regexps returns the compiled patterns, compiling them if the rule was
+// built as a literal. Invalid patterns match nothing.
+func (r Rule) regexps() []*regexp.Regexp {
+	if len(r.compiled) == len(r.Patterns) {
+		return r.compiled
+	}
+	var regexps []*regexp.Regexp
+	for _, pattern := range r.Patterns {
+		if re, err := compile(pattern); err == nil {
+			regexps = append(regexps, re)
+		}
+	}
+	return regexps
+}
+
+// Rules are checked in order; the first rule matching a file classifies it.
+type Rules []Rule
+
+// DefaultRules recognise tests, CI configuration, build configuration and
+// documentation.
+var DefaultRules = mustCompile(Rules{
+	{Type: "test", Patterns: []string{"*_test.go", "**/testdata/**", "**/__tests__/**", "*.test.*", "*.spec.*", "test_*.py", "*_test.py"}},
+	{Type: "ci", Patterns: []string{".github/workflows/**", ".gitlab-ci.yml", ".circleci/**", ".travis.yml", "Jenkinsfile", "azure-pipelines.yml", ".buildkite/**"}},
+	{Type: "build", Patterns: []string{"Makefile", "Dockerfile", "*.dockerfile", ".goreleaser.yml", ".goreleaser.yaml", "go.mod", "go.sum", "package.json", "package-lock.json", "yarn.lock", "pnpm-lock.yaml", "requirements*.txt", "pyproject.toml", "setup.py", "Cargo.toml", "Cargo.lock", "pom.xml", "*.gradle", "CMakeLists.txt"}},
+	{Type: "docs", Patterns: []string{"*.md", "*.mdx", "*.rst", "*.adoc", "docs/**", "doc/**"}},
+})
+
+// mustCompile compiles the patterns of built-in rules.
+func mustCompile(rules Rules) Rules {
+	for i := range rules {
+		rule, err := rules[i].compile()
+		if err != nil {
+			panic(err)
+		}
+		rules[i] = rule
+	}
+	return rules
+}
+
+// ParseRules parses rules in the form "type=glob|glob,...". Each type given
+// replaces the default patterns of that type, or is checked after the defaults
+// if it is new; an empty list of globs disables the type.
+func ParseRules(value string, validType func(string) bool) (Rules, error) {
+	rules := append(Rules(nil), DefaultRules...)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		commitType, globs, ok := strings.Cut(item, "=")
+		commitType = strings.TrimSpace(commitType)
+		if !ok || commitType == "" {
+			return nil, fmt.Errorf("invalid path rule %q, expected type=glob|glob", item)
+		}
+		if !validType(commitType) {
+			return nil, fmt.Errorf("unknown commit type %q in path rule", commitType)
+		}
+
+		var patterns []string
+		for _, glob := range strings.Split(globs, "|") {
+			if glob = strings.TrimSpace(glob); glob != "" {
+				patterns = append(patterns, glob)
+			}
+		}
+		rule, err := Rule{Type: commitType, Patterns: patterns}.compile()
+		if err != nil {
+			return nil, err
+		}
+		rules = rules.set(rule)
+	}
+	return rules, nil
+}
+
+// set replaces the rule of the same type, or appends a new one.
+func (r Rules) set(rule Rule) Rules {
+	for i := range r {
+		if r[i].Type == rule.Type {
+			r[i] = rule
+			return r
+		}
+	}
+	return append(r, rule)
+}
+
+// String formats the rules in the form read by ParseRules.
+func (r Rules) String() string {
+	items := make([]string, len(r))
+	for i, rule := range r {
+		items[i] = rule.Type + "=" + strings.Join(rule.Patterns, "|")
+	}
+	return strings.Join(items, ",")
+}
+
+// Classify returns the commit types implied by the paths of the changed
+// files, in order. It returns nil if a file matches no rule, since the change
+// then holds something the rules know nothing about.
+func (r Rules) Classify(files []string) []string {
+	if len(files) == 0 {
+		return nil
+	}
+
+	found := make(map[string]bool)
+	for _, file := range files {
+		commitType := r.match(file)
+		if commitType == "" {
+			return nil
+		}
+		found[commitType] = true
+	}
+
+	types := make([]string, 0, len(found))
+	for commitType := range found {
+		types = append(types, commitType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// match returns the type of the first rule matching the file, or "".
+func (r Rules) match(file string) string {
+	for _, rule := range r {
+		for _, re := range rule.regexps() {
+			if re.MatchString(file) {
+				return rule.Type
+			}
+		}
+	}
+	return ""
+}
+
+// compile converts a glob pattern into a regular expression matching file
+// paths. A pattern without a slash matches the file name in any directory.
+func compile(pattern string) (*regexp.Regexp, error) {
+	nameOnly := !strings.Contains(pattern, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if nameOnly {
+		b.WriteString("(?:.*/)?")
+	}
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch rest := string(runes[i:]); {
+		case strings.HasPrefix(rest, "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(rest, "**") && nameOnly:
+			b.WriteString("[^/]*")
+			i++
+		case strings.HasPrefix(rest, "**"):
+			b.WriteString(".*")
+			i++
+		case runes[i] == '*':
+			b.WriteString("[^/]*")
+		case runes[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(runes[i])))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
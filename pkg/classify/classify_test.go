@@ -0,0 +1,154 @@
+package classify
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+		match   []string
+		noMatch []string
+	}{
+		{
+			pattern: "*.md",
+			want:    `^(?:.*/)?[^/]*\.md$`,
+			match:   []string{"README.md", "docs/guide/intro.md", ".md"},
+			noMatch: []string{"README.mdx", "md/README.txt"},
+		},
+		{
+			pattern: "test_?.py",
+			want:    `^(?:.*/)?test_[^/]\.py$`,
+			match:   []string{"test_a.py", "pkg/test_b.py"},
+			noMatch: []string{"test_ab.py", "test_.py"},
+		},
+		{
+			pattern: "docs/**",
+			want:    `^docs/.*$`,
+			match:   []string{"docs/index.md", "docs/a/b/c.png"},
+			noMatch: []string{"pkg/docs/index.md", "docs"},
+		},
+		{
+			pattern: "**/__tests__/**",
+			want:    `^(?:.*/)?__tests__/.*$`,
+			match:   []string{"__tests__/a.js", "src/ui/__tests__/button.js"},
+			noMatch: []string{"src/__tests__", "src/not__tests__/a.js"},
+		},
+		{
+			pattern: ".github/workflows/*.yml",
+			want:    `^\.github/workflows/[^/]*\.yml$`,
+			match:   []string{".github/workflows/ci.yml"},
+			noMatch: []string{".github/workflows/nested/ci.yml", "x.github/workflows/ci.yml"},
+		},
+		{
+			pattern: "a**b",
+			want:    `^(?:.*/)?a[^/]*b$`,
+			match:   []string{"ab", "axxb", "dir/axb"},
+			noMatch: []string{"a/b", "ax/yb"},
+		},
+		{
+			pattern: "requirements*.txt",
+			want:    `^(?:.*/)?requirements[^/]*\.txt$`,
+			match:   []string{"requirements.txt", "py/requirements-dev.txt"},
+			noMatch: []string{"requirements.txt.bak"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			re, err := compile(tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if re.String() != tt.want {
+				t.Errorf("compile(%q) = %s, want %s", tt.pattern, re, tt.want)
+			}
+			for _, file := range tt.match {
+				if !re.MatchString(file) {
+					t.Errorf("%q does not match %q", tt.pattern, file)
+				}
+			}
+			for _, file := range tt.noMatch {
+				if re.MatchString(file) {
+					t.Errorf("%q matches %q", tt.pattern, file)
+				}
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{name: "tests", files: []string{"pkg/a_test.go", "web/__tests__/a.js"}, want: []string{"test"}},
+		{name: "docs", files: []string{"README.md", "docs/logo.png"}, want: []string{"docs"}},
+		{name: "mixed", files: []string{"go.mod", ".github/workflows/ci.yml"}, want: []string{"build", "ci"}},
+		{name: "unknown file", files: []string{"README.md", "main.go"}},
+		{name: "no files"},
+		// The test rule comes first, so a Markdown file in testdata is a test
+		{name: "first matching rule wins", files: []string{"pkg/testdata/golden.md"}, want: []string{"test"}},
+		{name: "spec before docs", files: []string{"docs/api.spec.md"}, want: []string{"test"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultRules.Classify(tt.files); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Classify(%q) = %q, want %q", tt.files, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	valid := func(commitType string) bool { return commitType != "bogus" }
+
+	rules, err := ParseRules("docs=*.txt|guides/**, chore=*.lock ,test=", valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{file: "notes.txt", want: []string{"docs"}},
+		{file: "guides/setup.md", want: []string{"docs"}},
+		// Replacing the docs patterns drops *.md
+		{file: "README.md"},
+		// An empty list disables the type
+		{file: "a_test.go"},
+		// New types are checked after the defaults
+		{file: "Cargo.lock", want: []string{"build"}},
+		{file: "Gemfile.lock", want: []string{"chore"}},
+	}
+	for _, tt := range tests {
+		if got := rules.Classify([]string{tt.file}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Classify(%q) = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+
+	if got := rules.String(); !strings.HasPrefix(got, "test=,ci=") || !strings.HasSuffix(got, ",docs=*.txt|guides/**,chore=*.lock") {
+		t.Errorf("String() = %q", got)
+	}
+	// The defaults are left alone
+	if got := DefaultRules.Classify([]string{"README.md"}); !reflect.DeepEqual(got, []string{"docs"}) {
+		t.Errorf("DefaultRules changed: Classify(README.md) = %q", got)
+	}
+
+	for _, value := range []string{"docs", "=*.md", "bogus=*.md"} {
+		if _, err := ParseRules(value, valid); err == nil {
+			t.Errorf("ParseRules(%q) succeeded", value)
+		}
+	}
+}
+
+func TestRuleLiteral(t *testing.T) {
+	rules := Rules{{Type: "docs", Patterns: []string{"*.md"}}}
+	if got := rules.Classify([]string{"docs/a.md"}); !reflect.DeepEqual(got, []string{"docs"}) {
+		t.Errorf("Classify() = %q, want docs", got)
+	}
+}
@@ -1,18 +1,15 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
-	"os"
-	"path/filepath"
-	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 
-	"github.com/tolgaOzen/combo/internal"
+	"github.com/tolgaOzen/combo/pkg/config"
 	"github.com/tolgaOzen/combo/pkg/git"
+	"github.com/tolgaOzen/combo/pkg/issue"
 	"github.com/tolgaOzen/combo/pkg/prompt"
 )
 
@@ -31,6 +28,7 @@ func NewBranchCommand() *cobra.Command {
 		RunE:  branch(),
 		Args:  cobra.NoArgs,
 	}
+	command.Flags().String("issue", "", "issue key for the branch template (defaults to the one found in the current branch name)")
 	return command
 }
 
@@ -102,51 +100,26 @@ func (m branchModel) View() string {
 
 func branch() func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
-		dir, err := os.UserHomeDir()
+		cfg, err := loadConfig(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to get user home directory: %w", err)
+			return err
 		}
 
-		// Configuration file path
-		configPath := filepath.Join(dir, ".combo", "config")
-
-		// Ensure the config directory and file exist
-		defaultContent := `# Default configuration
-openai_api_key=
-prompt_locale=en-US
-prompt_max_length=72
-`
-		if err := EnsureConfig(configPath, defaultContent); err != nil {
-			return fmt.Errorf("failed to ensure configuration: %w", err)
-		}
-
-		// Load configuration
-		config, err := LoadConfig(configPath)
+		apiKey, err := cfg.APIKey(readPassphrase)
 		if err != nil {
-			return fmt.Errorf("failed to load configuration: %w", err)
-		}
-
-		// Retrieve values from the config
-		apiKey, ok := config["openai_api_key"]
-		if !ok || apiKey == "" {
-			return fmt.Errorf("missing or empty 'openai_api_key' in configuration")
-		}
-
-		locale, ok := config["prompt_locale"]
-		if !ok || locale == "" {
-			locale = "en-US" // Default locale
+			return err
 		}
 
 		// Initialize the OpenAI client
-		client := internal.NewOpenAIClient(apiKey)
+		client, err := newClient(cmd, cfg, apiKey)
+		if err != nil {
+			return err
+		}
 
 		// Generate a prompt
-		p, err := prompt.GenerateBranchNamePrompt(
-			prompt.WithLocale(prompt.Locale(locale)),
-			prompt.WithMaxLength(30),
-		)
+		p, err := branchPrompt(cfg)
 		if err != nil {
-			return fmt.Errorf("failed to generate prompt: %w", err)
+			return err
 		}
 
 		diff, err := git.GetDifferences()
@@ -154,26 +127,24 @@ prompt_max_length=72
 			return fmt.Errorf("failed to get git differences: %w", err)
 		}
 
-		// Prepare the chat completion request
-		request := internal.CreateChatCompletionRequest(p, diff)
-
-		// Set up a context with a timeout
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-
-		// Send the chat completion request and handle the response
-		response, err := client.SendChatCompletionRequest(ctx, request)
+		// Generated branch name
+		name, err := generateMessage(client, cfg.Model, p, diff)
 		if err != nil {
-			return fmt.Errorf("chat completion request failed: %w", err)
+			return err
 		}
 
-		// Ensure the chat completion response and commit message are valid
-		if len(response.Choices) == 0 || response.Choices[0].Message.Content == "" {
-			return fmt.Errorf("no commit message generated from the OpenAI response")
+		issueKey, err := resolveIssue(cmd, cfg)
+		if err != nil {
+			return err
 		}
 
-		// Generated commit message
-		message := response.Choices[0].Message.Content
+		message, err := issue.RenderBranchName(cfg.BranchTemplate, issue.BranchData{
+			Name:  name,
+			Issue: issueKey,
+		})
+		if err != nil {
+			return err
+		}
 
 		// Bubble Tea program setup
 		program := tea.NewProgram(&branchModel{message: message})
@@ -185,7 +156,7 @@ prompt_max_length=72
 		// Check user choice
 		if result, ok := mod.(branchModel); ok && result.choice == "yes" {
 			// Run git commit command
-			if err := runGitBranch(message); err != nil {
+			if err := git.CreateBranch(message); err != nil {
 				return fmt.Errorf("failed to run git commit: %w", err)
 			}
 		}
@@ -193,3 +164,20 @@ prompt_max_length=72
 		return nil
 	}
 }
+
+// branchPrompt renders the effective branch name prompt for the staged change.
+func branchPrompt(cfg *config.Config) (string, error) {
+	opts, err := promptContext()
+	if err != nil {
+		return "", err
+	}
+
+	p, err := prompt.GenerateBranchNamePrompt(append([]prompt.Option{
+		prompt.WithLocale(cfg.PromptLocale),
+		prompt.WithMaxLength(30),
+	}, opts...)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate prompt: %w", err)
+	}
+	return p, nil
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tolgaOzen/combo/pkg/cache"
+)
+
+// NewCacheCommand - returns a new cobra command for the response cache
+func NewCacheCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the response cache",
+	}
+
+	// Add subcommands
+	command.AddCommand(newCacheClearCommand())
+
+	return command
+}
+
+// newCacheClearCommand - returns a cobra command for purging the response cache
+func newCacheClearCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear",
+		Short: "Remove every cached response",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := cache.DefaultDir()
+			if err != nil {
+				return err
+			}
+
+			removed, err := cache.New(dir, 0, 0).Clear()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Removed %d cached response(s) from %s\n", removed, dir)
+			return nil
+		},
+	}
+}
@@ -1,27 +1,32 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strconv"
-	"time"
+	"slices"
+	"strings"
 
 	"github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 
-	"github.com/tolgaOzen/combo/internal"
+	"github.com/tolgaOzen/combo/pkg/classify"
+	"github.com/tolgaOzen/combo/pkg/config"
+	"github.com/tolgaOzen/combo/pkg/deps"
 	"github.com/tolgaOzen/combo/pkg/git"
+	"github.com/tolgaOzen/combo/pkg/goast"
+	"github.com/tolgaOzen/combo/pkg/history"
+	"github.com/tolgaOzen/combo/pkg/issue"
 	"github.com/tolgaOzen/combo/pkg/prompt"
 )
 
 // Define the Bubble Tea model
 type commitModel struct {
-	message  string
-	choice   string
-	quitting bool
+	message    string
+	commitType string
+	scope      string
+	breaking   []string
+	choice     string
+	quitting   bool
 }
 
 // Init Initial model setup
@@ -35,9 +40,11 @@ func (m commitModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "y", "Y", "", tea.KeyEnter.String():
-			return commitModel{message: m.message, choice: "yes", quitting: true}, tea.Quit
+			m.choice, m.quitting = "yes", true
+			return m, tea.Quit
 		case "n", "N":
-			return commitModel{message: m.message, choice: "no", quitting: true}, tea.Quit
+			m.choice, m.quitting = "no", true
+			return m, tea.Quit
 		case tea.KeyCtrlC.String(), tea.KeyEsc.String():
 			return m, tea.Quit
 		}
@@ -51,8 +58,8 @@ func (m commitModel) View() string {
 		if m.choice == "yes" {
 			return fmt.Sprintf(
 				"%s\n\n%s\n",
-				lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("10")).Render("✔ Commit executed successfully!"),
-				lipgloss.NewStyle().Foreground(lipgloss.Color("7")).Italic(true).Render("Your changes have been committed. You can push them to your remote repository."),
+				lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("10")).Render("✔ Message accepted."),
+				lipgloss.NewStyle().Foreground(lipgloss.Color("7")).Italic(true).Render("Committing your changes with it..."),
 			)
 		}
 		return fmt.Sprintf(
@@ -76,131 +83,540 @@ func (m commitModel) View() string {
 		Italic(true).
 		PaddingLeft(2)
 
+	labelStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		PaddingLeft(2)
+
+	fieldStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("5")).
+		Bold(true)
+
 	promptStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("3")).
 		PaddingTop(1)
 
+	warningStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("9")).
+		Bold(true)
+
+	breakingStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("9")).
+		PaddingLeft(2)
+
 	// Render sections
 	brand := brandStyle.Render("Generating your commit message...")
 	header := headerStyle.Render("Here’s your commit message:")
 	message := messageStyle.Render(fmt.Sprintf("➤ %s", m.message))
 	prompt := promptStyle.Render("Would you like to use this message? (Y/n):")
 
+	// Show the type and scope of conventional commits on their own line
+	fields := ""
+	if m.commitType != "" {
+		fields = labelStyle.Render("type ") + fieldStyle.Render(m.commitType)
+		if m.scope != "" {
+			fields += labelStyle.Render("scope ") + fieldStyle.Render(m.scope)
+		}
+		fields += "\n\n"
+	}
+
+	// Warn about incompatible API changes before the message is accepted
+	warning := ""
+	if len(m.breaking) > 0 {
+		warning = warningStyle.Render("⚠ This commit breaks the public API:") + "\n"
+		for _, change := range m.breaking {
+			warning += breakingStyle.Render("• "+change) + "\n"
+		}
+		warning += "\n"
+	}
+
+	// Combine output
+	return fmt.Sprintf("%s\n\n%s%s\n\n%s%s\n%s", brand, warning, header, fields, message, prompt)
+}
+
+// Define the Bubble Tea model for staging the files changed by hooks
+type restageModel struct {
+	files    []string
+	choice   string
+	quitting bool
+}
+
+// Init Initial model setup
+func (m restageModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles user input and state changes
+func (m restageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "y", "Y", "", tea.KeyEnter.String():
+			m.choice, m.quitting = "yes", true
+			return m, tea.Quit
+		case "n", "N":
+			m.choice, m.quitting = "no", true
+			return m, tea.Quit
+		case tea.KeyCtrlC.String(), tea.KeyEsc.String():
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+// View renders the UI for staging the files changed by hooks
+func (m restageModel) View() string {
+	if m.quitting {
+		if m.choice == "yes" {
+			return fmt.Sprintf(
+				"%s\n\n%s\n",
+				lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("10")).Render("✔ Changes staged."),
+				lipgloss.NewStyle().Foreground(lipgloss.Color("7")).Italic(true).Render("A new message is generated for the updated files."),
+			)
+		}
+		return fmt.Sprintf(
+			"%s\n\n%s\n",
+			lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("9")).Render("✘ Changes left unstaged."),
+			lipgloss.NewStyle().Foreground(lipgloss.Color("7")).Italic(true).Render("No changes have been committed. Review them and retry with combo commit --reuse."),
+		)
+	}
+
+	// Define styles
+	headerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("6")).
+		Underline(true)
+
+	fileStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("2")).
+		PaddingLeft(2)
+
+	promptStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("3")).
+		PaddingTop(1)
+
+	// Render sections
+	header := headerStyle.Render("The commit hooks changed these files:")
+	var files strings.Builder
+	for _, file := range m.files {
+		files.WriteString(fileStyle.Render("• "+file) + "\n")
+	}
+	prompt := promptStyle.Render("Would you like to stage them and generate a new message? (Y/n):")
+
 	// Combine output
-	return fmt.Sprintf("%s\n\n%s\n\n%s\n%s", brand, header, message, prompt)
+	return fmt.Sprintf("%s\n\n%s%s", header, files.String(), prompt)
 }
 
 // NewCommitCommand Commit command logic with Bubble Tea integration
 func NewCommitCommand() *cobra.Command {
 	command := &cobra.Command{
-		Use:   "commit",
+		Use:   "commit [-- <git commit flags>]",
 		Short: "Commit the changes",
-		RunE:  commit(),
-		Args:  cobra.NoArgs,
+		Long: `Generate a message for the staged changes and commit them.
+Flags given after -- are passed to git commit as they are.`,
+		RunE: commit(),
+		Args: passthroughArgs,
 	}
+	command.Flags().String("issue", "", "issue key to reference (defaults to the one found in the branch name)")
+	command.Flags().Bool("amend", false, "replace the last commit, describing its changes together with the staged ones")
+	command.Flags().StringP("gpg-sign", "S", "", "GPG-sign the commit, with the given key or the configured one")
+	command.Flags().Lookup("gpg-sign").NoOptDefVal = defaultSignKey
+	command.Flags().Bool("no-verify", false, "skip the pre-commit and commit-msg hooks")
+	command.Flags().String("author", "", "override the commit author (\"Name <email>\")")
+	command.Flags().BoolP("signoff", "s", false, "add a Signed-off-by trailer")
+	command.Flags().String("fixup", "", "commit a fixup for the given commit, to be squashed by git rebase --autosquash")
+	command.Flags().Bool("reuse", false, "commit with the message saved by the last attempt instead of generating one")
 	return command
 }
 
+// defaultSignKey stands for -S given without a key, which signs with the configured one.
+const defaultSignKey = "default"
+
+// passthroughArgs accepts arguments only after --, where they are meant for git commit.
+func passthroughArgs(cmd *cobra.Command, args []string) error {
+	if cmd.ArgsLenAtDash() != 0 && len(args) > 0 {
+		return fmt.Errorf("unexpected arguments %q; pass git commit flags after --", args)
+	}
+	return nil
+}
+
+// commitOptions reads the git commit flags and the arguments passed through after --.
+func commitOptions(cmd *cobra.Command, args []string) (git.CommitOptions, error) {
+	var opts git.CommitOptions
+	var err error
+	if opts.Amend, err = cmd.Flags().GetBool("amend"); err != nil {
+		return opts, err
+	}
+	if opts.SignKey, err = cmd.Flags().GetString("gpg-sign"); err != nil {
+		return opts, err
+	}
+	opts.Sign = cmd.Flags().Changed("gpg-sign")
+	if opts.SignKey == defaultSignKey {
+		opts.SignKey = ""
+	}
+	if opts.NoVerify, err = cmd.Flags().GetBool("no-verify"); err != nil {
+		return opts, err
+	}
+	if opts.Author, err = cmd.Flags().GetString("author"); err != nil {
+		return opts, err
+	}
+	if opts.Signoff, err = cmd.Flags().GetBool("signoff"); err != nil {
+		return opts, err
+	}
+	opts.Args = args
+	return opts, nil
+}
+
 func commit() func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
-		dir, err := os.UserHomeDir()
+		opts, err := commitOptions(cmd, args)
 		if err != nil {
-			return fmt.Errorf("failed to get user home directory: %w", err)
+			return err
 		}
-
-		// Configuration file path
-		configPath := filepath.Join(dir, ".combo", "config")
-
-		// Ensure the config directory and file exist
-		defaultContent := `# Default configuration
-openai_api_key=
-prompt_locale=en-US
-prompt_max_length=72
-`
-		if err := EnsureConfig(configPath, defaultContent); err != nil {
-			return fmt.Errorf("failed to ensure configuration: %w", err)
+		fixup, err := cmd.Flags().GetString("fixup")
+		if err != nil {
+			return err
+		}
+		reuse, err := cmd.Flags().GetBool("reuse")
+		if err != nil {
+			return err
+		}
+		if fixup != "" && opts.Amend {
+			return fmt.Errorf("--fixup cannot be combined with --amend")
+		}
+		if fixup != "" && reuse {
+			return fmt.Errorf("--fixup cannot be combined with --reuse")
 		}
 
-		// Load configuration
-		config, err := LoadConfig(configPath)
+		cfg, err := loadConfig(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to load configuration: %w", err)
+			return err
 		}
 
-		// Retrieve values from the config
-		apiKey, ok := config["openai_api_key"]
-		if !ok || apiKey == "" {
-			return fmt.Errorf("missing or empty 'openai_api_key' in configuration")
+		for {
+			model, err := newCommitModel(cmd, cfg, opts, fixup, reuse)
+			if err != nil {
+				return err
+			}
+			restaged, err := confirmCommit(model, opts)
+			if err != nil || !restaged {
+				return err
+			}
+			// The files the hooks changed are staged again; describe the change anew
+			reuse = false
 		}
+	}
+}
 
-		locale, ok := config["prompt_locale"]
-		if !ok || locale == "" {
-			locale = "en-US" // Default locale
+// newCommitModel prepares the confirmation of a commit of the staged change,
+// with the saved message, a fixup message or a generated one.
+func newCommitModel(cmd *cobra.Command, cfg *config.Config, opts git.CommitOptions, fixup string, reuse bool) (commitModel, error) {
+	// The staged change is read once and shared by every step below. An
+	// amended commit holds the changes of HEAD as well.
+	var staged *git.DiffResult
+	var err error
+	if opts.Amend {
+		if _, err := git.RevParse("HEAD"); err != nil {
+			return commitModel{}, fmt.Errorf("there is no commit to amend")
 		}
+		staged, err = git.FetchAmendDiff()
+	} else {
+		staged, err = git.FetchStagedDiff()
+	}
+	if err == nil && staged == nil {
+		err = git.ErrNoStagedChanges
+	}
+	if err != nil {
+		return commitModel{}, fmt.Errorf("failed to get git differences: %w", err)
+	}
 
-		maxLengthStr, ok := config["prompt_max_length"]
-		if !ok || maxLengthStr == "" {
-			maxLengthStr = "72" // Default max length
+	// A retry commits with the message of the attempt the hooks rejected
+	if reuse {
+		message, err := git.SavedMessage()
+		if err != nil {
+			return commitModel{}, err
 		}
-		maxLength, err := strconv.Atoi(maxLengthStr)
+		return commitModel{message: message}, nil
+	}
+
+	// A fixup is named after the commit it fixes, without the model
+	if fixup != "" {
+		message, err := fixupMessage(fixup)
 		if err != nil {
-			return fmt.Errorf("invalid 'prompt_max_length' in configuration: %w", err)
+			return commitModel{}, err
 		}
+		return commitModel{message: message}, nil
+	}
 
-		// Initialize the OpenAI client
-		client := internal.NewOpenAIClient(apiKey)
+	issueKey, err := resolveIssue(cmd, cfg)
+	if err != nil {
+		return commitModel{}, err
+	}
 
-		// Generate a prompt
-		p, err := prompt.GenerateCommitPrompt(
-			prompt.Conventional,
-			prompt.WithLocale(prompt.Locale(locale)),
-			prompt.WithMaxLength(maxLength),
-		)
-		if err != nil {
-			return fmt.Errorf("failed to generate prompt: %w", err)
+	// Dependency bumps are described from the manifests, without the model
+	var bumps []deps.Bump
+	if cfg.DependencyMessages {
+		if bumps, err = deps.Detect(staged); err != nil {
+			return commitModel{}, fmt.Errorf("failed to read dependency changes: %w", err)
 		}
+	}
 
-		diff, err := git.GetDifferences()
+	// Generated commit message
+	var generated prompt.CommitMessage
+	described := false
+	if len(bumps) > 0 {
+		generated, described = deps.Message(bumps, cfg.CommitStyle, cfg.CommitTypes)
+	}
+	if !described {
+		if generated, err = generateStagedMessage(cmd, cfg, issueKey, staged); err != nil {
+			return commitModel{}, err
+		}
+	}
+
+	// Flag incompatible changes to the exported Go API
+	var breaking []goast.Incompatibility
+	if cfg.DetectBreaking {
+		breaking, err = goast.BreakingChanges(staged)
 		if err != nil {
-			return fmt.Errorf("failed to get git differences: %w", err)
+			return commitModel{}, fmt.Errorf("failed to compare the Go API: %w", err)
 		}
+		markBreaking(&generated, breaking)
+	}
+	message := issue.Apply(generated.Render(cfg.CommitStyle), issueKey, cfg.IssueReferenceFormat)
+
+	// Bubble Tea program setup
+	model := commitModel{message: message}
+	for _, change := range breaking {
+		model.breaking = append(model.breaking, change.String())
+	}
+	if cfg.CommitStyle != prompt.Empty {
+		model.commitType, model.scope = generated.Type, generated.Scope
+	}
+	return model, nil
+}
 
-		// Prepare the chat completion request
-		request := internal.CreateChatCompletionRequest(p, diff)
+// confirmCommit shows the message and commits the staged changes with it if
+// the user accepts. The message is saved until the commit succeeds, so that a
+// commit rejected by a hook can be retried with --reuse. When the hooks changed
+// files on the way, the user can stage them instead, and restaged reports it.
+func confirmCommit(model commitModel, opts git.CommitOptions) (restaged bool, err error) {
+	program := tea.NewProgram(&model)
+	mod, err := program.Run()
+	if err != nil {
+		return false, fmt.Errorf("bubble tea program encountered an error: %w", err)
+	}
 
-		// Set up a context with a timeout
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
+	// Check user choice
+	if result, ok := mod.(commitModel); !ok || result.choice != "yes" {
+		return false, nil
+	}
 
-		// Send the chat completion request and handle the response
-		response, err := client.SendChatCompletionRequest(ctx, request)
-		if err != nil {
-			return fmt.Errorf("chat completion request failed: %w", err)
+	if err := git.SaveMessage(model.message); err != nil {
+		return false, err
+	}
+
+	// Formatters run as hooks leave their changes unstaged
+	before, err := git.UnstagedFiles()
+	if err != nil {
+		return false, err
+	}
+
+	// Run git commit command
+	if err := git.CommitStaged(model.message, opts); err != nil {
+		err = fmt.Errorf("failed to run git commit: %w; retry with the saved message using 'combo commit --reuse'", err)
+
+		changed, statusErr := hookChanges(before)
+		if statusErr != nil || len(changed) == 0 {
+			return false, err
+		}
+		restage, promptErr := confirmRestage(changed)
+		if promptErr != nil {
+			return false, promptErr
 		}
+		if !restage {
+			return false, err
+		}
+		if err := git.Stage(changed...); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
 
-		// Ensure the chat completion response and commit message are valid
-		if len(response.Choices) == 0 || response.Choices[0].Message.Content == "" {
-			return fmt.Errorf("no commit message generated from the OpenAI response")
+	return false, git.ClearMessage()
+}
+
+// hookChanges returns the files that became unstaged since before was taken.
+// Files that were already unstaged are left out even if a hook changed them
+// too, since staging them would also stage the user's own edits.
+func hookChanges(before []string) ([]string, error) {
+	after, err := git.UnstagedFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for _, file := range after {
+		if !slices.Contains(before, file) {
+			changed = append(changed, file)
 		}
+	}
+	return changed, nil
+}
 
-		// Generated commit message
-		message := response.Choices[0].Message.Content
+// confirmRestage lists the files the hooks changed and asks whether to stage them.
+func confirmRestage(files []string) (bool, error) {
+	program := tea.NewProgram(&restageModel{files: files})
+	mod, err := program.Run()
+	if err != nil {
+		return false, fmt.Errorf("bubble tea program encountered an error: %w", err)
+	}
 
-		// Bubble Tea program setup
-		program := tea.NewProgram(&commitModel{message: message})
-		mod, err := program.Run()
-		if err != nil {
-			return fmt.Errorf("bubble tea program encountered an error: %w", err)
+	result, ok := mod.(restageModel)
+	return ok && result.choice == "yes", nil
+}
+
+// fixupMessage returns the message git commit --fixup gives a fixup of rev.
+func fixupMessage(rev string) (string, error) {
+	commits, err := git.ListCommits(rev)
+	if err != nil {
+		return "", err
+	}
+	if len(commits) != 1 {
+		return "", fmt.Errorf("unknown revision %s", rev)
+	}
+	return "fixup! " + commits[0].Subject(), nil
+}
+
+// generateStagedMessage asks the model for a message describing the staged change.
+func generateStagedMessage(cmd *cobra.Command, cfg *config.Config, issueKey string, staged *git.DiffResult) (prompt.CommitMessage, error) {
+	apiKey, err := cfg.APIKey(readPassphrase)
+	if err != nil {
+		return prompt.CommitMessage{}, err
+	}
+
+	// Initialize the OpenAI client
+	client, err := newClient(cmd, cfg, apiKey)
+	if err != nil {
+		return prompt.CommitMessage{}, err
+	}
+
+	// Generate a prompt
+	p, err := commitPrompt(cfg, issueKey, staged.Paths())
+	if err != nil {
+		return prompt.CommitMessage{}, err
+	}
+
+	diff, err := commitDiff(cfg, staged)
+	if err != nil {
+		return prompt.CommitMessage{}, err
+	}
+
+	return generateCommitMessage(client, cfg, p, diff, pathTypes(cfg, staged.Paths()))
+}
+
+// commitPrompt renders the effective commit prompt for a change to the given
+// files, which are the staged ones unless a commit is amended.
+func commitPrompt(cfg *config.Config, issueKey string, staged []string) (string, error) {
+	opts, err := promptContext()
+	if err != nil {
+		return "", err
+	}
+
+	examples, err := history.Examples(cfg.HistoryExamples, cfg.HistorySelection, staged)
+	if err != nil {
+		return "", err
+	}
+
+	p, err := prompt.GenerateCommitPrompt(cfg.CommitStyle, append([]prompt.Option{
+		prompt.WithLocale(cfg.PromptLocale),
+		prompt.WithMaxLength(cfg.PromptMaxLength),
+		prompt.WithIssue(issueKey),
+		prompt.WithOutputFormat(cfg.OutputFormat),
+		prompt.WithCommitTypes(cfg.CommitTypes),
+		prompt.WithScopes(cfg.CommitScopes),
+		prompt.WithPathTypes(pathTypes(cfg, staged)),
+		prompt.WithExamples(examples),
+	}, append(opts, prompt.WithFiles(staged))...)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate prompt: %w", err)
+	}
+	return p, nil
+}
+
+// pathTypes returns the commit types implied by the paths of the staged files,
+// limited to the configured commit types. Only conventional commits use them.
+func pathTypes(cfg *config.Config, staged []string) []string {
+	if cfg.CommitStyle != prompt.Conventional {
+		return nil
+	}
+	rules := cfg.PathRules
+	if rules == nil {
+		rules = classify.DefaultRules
+	}
+
+	types := rules.Classify(staged)
+	if len(cfg.CommitTypes) == 0 {
+		return types
+	}
+	var allowed []string
+	for _, t := range types {
+		if slices.Contains(cfg.CommitTypes, t) {
+			allowed = append(allowed, t)
 		}
+	}
+	return allowed
+}
+
+// commitDiff returns what the model is shown of the staged change: the patch,
+// preceded by a summary of the changed Go declarations unless go_summary is
+// off. In replace mode the summary stands in for the patch of the Go files.
+func commitDiff(cfg *config.Config, staged *git.DiffResult) (string, error) {
+	diff, err := staged.Render()
+	if err != nil {
+		return "", fmt.Errorf("failed to get git differences: %w", err)
+	}
+	if cfg.GoSummary == goast.Off {
+		return diff, nil
+	}
 
-		// Check user choice
-		if result, ok := mod.(commitModel); ok && result.choice == "yes" {
-			// Run git commit command
-			if err := runGitCommit(message); err != nil {
-				return fmt.Errorf("failed to run git commit: %w", err)
+	files, err := goast.Summarize(staged)
+	if err != nil {
+		return "", fmt.Errorf("failed to summarise Go changes: %w", err)
+	}
+	summary := goast.Format(files)
+	if summary == "" {
+		return diff, nil
+	}
+
+	if cfg.GoSummary == goast.Replace {
+		// Keep the patch of every file the summary does not describe, and of
+		// files where only bodies changed, since the summary only names them
+		summarised := make(map[string]bool)
+		for _, file := range files {
+			if file.Err == nil && !file.BodyOnly() {
+				summarised[file.Path] = true
 			}
 		}
+		rest := staged.Filter(func(file git.FileChange) bool { return !summarised[file.Path] })
+		if len(rest.Files) == 0 {
+			return summary, nil
+		}
+		if diff, err = rest.Render(); err != nil {
+			return "", fmt.Errorf("failed to get git differences: %w", err)
+		}
+	}
+	return summary + "\n\n" + diff, nil
+}
 
-		return nil
+// markBreaking marks the message as a breaking change and, unless the model
+// already wrote one, adds a BREAKING CHANGE footer listing the incompatibilities.
+func markBreaking(msg *prompt.CommitMessage, changes []goast.Incompatibility) {
+	if len(changes) == 0 {
+		return
+	}
+	msg.Breaking = true
+	for _, footer := range msg.Footers {
+		if strings.HasPrefix(footer, "BREAKING CHANGE:") || strings.HasPrefix(footer, "BREAKING-CHANGE:") {
+			return
+		}
 	}
+	msg.Footers = append(msg.Footers, goast.Footer(changes))
 }
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/tolgaOzen/combo/pkg/config"
+	"github.com/tolgaOzen/combo/pkg/git"
+	"github.com/tolgaOzen/combo/pkg/goast"
+)
+
+// stageFiles commits the base files to a new repository, stages the changed
+// files on top and returns the staged change. The repository is used by the
+// git package until the test ends.
+func stageFiles(t *testing.T, base, changed map[string]string) *git.DiffResult {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	git.Use(git.NewGoGitRepository(repo))
+	t.Cleanup(func() { git.Use(git.NewExecRepository("")) })
+
+	write := func(files map[string]string) {
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := worktree.Add(name); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	write(base)
+	signature := &object.Signature{Name: "Test", Email: "test@example.com", When: time.Unix(1700000000, 0)}
+	if _, err := worktree.Commit("initial", &gogit.CommitOptions{Author: signature, Committer: signature}); err != nil {
+		t.Fatal(err)
+	}
+	write(changed)
+
+	staged, err := git.FetchStagedDiff()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return staged
+}
+
+func TestCommitDiffReplace(t *testing.T) {
+	staged := stageFiles(t,
+		map[string]string{
+			"api.go":    "package api\n\nfunc Get() error { return nil }\n",
+			"body.go":   "package api\n\nfunc helper() int { return 1 }\n",
+			"README.md": "# api\n",
+		},
+		map[string]string{
+			"api.go":    "package api\n\nfunc Get(id string) error { return nil }\n",
+			"body.go":   "package api\n\nfunc helper() int { return 2 }\n",
+			"README.md": "# api\n\nDocs.\n",
+		})
+
+	tests := []struct {
+		mode       goast.Mode
+		summary    bool
+		patched    []string
+		notPatched []string
+	}{
+		{mode: goast.Off, patched: []string{"api.go", "body.go", "README.md"}},
+		{mode: goast.Append, summary: true, patched: []string{"api.go", "body.go", "README.md"}},
+		{mode: goast.Replace, summary: true, patched: []string{"body.go", "README.md"}, notPatched: []string{"api.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			diff, err := commitDiff(&config.Config{GoSummary: tt.mode}, staged)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.HasPrefix(diff, "Go declarations changed:\n"); got != tt.summary {
+				t.Errorf("summary shown = %v, want %v:\n%s", got, tt.summary, diff)
+			}
+			for _, file := range tt.patched {
+				if !strings.Contains(diff, "+++ b/"+file) {
+					t.Errorf("patch of %s is missing:\n%s", file, diff)
+				}
+			}
+			for _, file := range tt.notPatched {
+				if strings.Contains(diff, "+++ b/"+file) {
+					t.Errorf("patch of %s is sent with the summary:\n%s", file, diff)
+				}
+			}
+		})
+	}
+}
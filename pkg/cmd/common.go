@@ -1,117 +1,254 @@
 package cmd
 
 import (
-	"bufio"
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/tolgaOzen/combo/internal"
+	"github.com/tolgaOzen/combo/pkg/cache"
+	"github.com/tolgaOzen/combo/pkg/config"
+	"github.com/tolgaOzen/combo/pkg/git"
+	"github.com/tolgaOzen/combo/pkg/issue"
+	"github.com/tolgaOzen/combo/pkg/prompt"
+	"github.com/tolgaOzen/combo/pkg/usage"
 )
 
-// LoadConfig loads key-value pairs from a configuration file
-func LoadConfig(filePath string) (map[string]string, error) {
-	// Sanitize and validate the file path
-	filePath = filepath.Clean(filePath)
-	trustedDir, err := getConfigDirectory()
+// loadConfig ensures ~/.combo/config exists and resolves the layered configuration,
+// applying any --set overrides.
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	// Ensure the config directory and file exist
+	if err := config.EnsureUserFile(); err != nil {
+		return nil, fmt.Errorf("failed to ensure configuration: %w", err)
+	}
+
+	overrides, err := configOverrides(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.Load(overrides, repository)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// configOverrides returns the key=value pairs given with --set.
+func configOverrides(cmd *cobra.Command) ([]string, error) {
+	if cmd.Flags().Lookup("set") == nil {
+		return nil, nil
+	}
+	return cmd.Flags().GetStringArray("set")
+}
+
+// readPassphrase returns the credential file passphrase from COMBO_PASSPHRASE,
+// or asks for it on the terminal without echoing it.
+func readPassphrase() (string, error) {
+	if passphrase := os.Getenv("COMBO_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("the API key is encrypted; set COMBO_PASSPHRASE or run interactively")
+	}
+
+	fmt.Fprint(os.Stderr, "Passphrase for ~/.combo/credentials: ")
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get trusted directory: %w", err)
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
 	}
-	if !strings.HasPrefix(filePath, trustedDir) {
-		return nil, fmt.Errorf("file path is outside the trusted directory: %s", filePath)
+
+	return string(passphrase), nil
+}
+
+// resolveIssue returns the issue key given with --issue, or the one found in the current branch name.
+func resolveIssue(cmd *cobra.Command, cfg *config.Config) (string, error) {
+	key, err := cmd.Flags().GetString("issue")
+	if err != nil {
+		return "", err
+	}
+	if key != "" {
+		return key, nil
 	}
 
-	config := make(map[string]string)
+	branch, err := git.CurrentBranch()
+	if err != nil {
+		return "", err
+	}
 
-	file, err := os.Open(filePath)
+	return issue.Resolve(branch, cfg.IssuePattern)
+}
+
+// recentCommitCount is how many commit subjects prompt templates can see.
+const recentCommitCount = 10
+
+// promptDirs returns the directories searched for prompt template overrides:
+// the repository's .combo/prompts, then ~/.combo/prompts.
+func promptDirs() ([]string, error) {
+	var dirs []string
+	if root, err := git.RepoRoot(); err == nil {
+		dirs = append(dirs, filepath.Join(root, ".combo", "prompts"))
+	}
+
+	dir, err := config.UserDir()
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
+	return append(dirs, filepath.Join(dir, "prompts")), nil
+}
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		// Skip empty lines or comments
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
+// promptContext returns the prompt options describing the staged change: the
+// template directories, the current branch, the staged files and the latest commits.
+func promptContext() ([]prompt.Option, error) {
+	dirs, err := promptDirs()
+	if err != nil {
+		return nil, err
+	}
 
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid configuration line: %s", line)
-		}
+	branch, err := git.CurrentBranch()
+	if err != nil {
+		return nil, err
+	}
 
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-		config[key] = value
+	files, err := git.StagedFiles()
+	if err != nil {
+		return nil, err
 	}
 
-	if err := scanner.Err(); err != nil {
+	commits, err := git.RecentCommits(recentCommitCount)
+	if err != nil {
 		return nil, err
 	}
+	subjects := make([]string, 0, len(commits))
+	for _, c := range commits {
+		subjects = append(subjects, c.Subject())
+	}
 
-	return config, nil
+	return []prompt.Option{
+		prompt.WithTemplateDirs(dirs...),
+		prompt.WithBranch(branch),
+		prompt.WithFiles(files),
+		prompt.WithRecentCommits(subjects),
+	}, nil
 }
 
-// EnsureConfig ensures the directory and configuration file exist
-func EnsureConfig(filePath, defaultContent string) error {
-	// Sanitize and validate the file path
-	filePath = filepath.Clean(filePath)
-	trustedDir, err := getConfigDirectory()
+// newClient initializes the OpenAI or Azure OpenAI client with the configured
+// retry policy, response cache and usage tracking. The cache is skipped when --no-cache is given.
+func newClient(cmd *cobra.Command, cfg *config.Config, apiKey string) (*internal.OpenAIClient, error) {
+	policy := internal.DefaultRetryPolicy
+	policy.MaxRetries = cfg.MaxRetries
+	policy.BaseDelay = cfg.RetryBaseDelay
+
+	ledger, err := usage.DefaultPath()
 	if err != nil {
-		return fmt.Errorf("failed to get trusted directory: %w", err)
+		return nil, err
 	}
-	if !strings.HasPrefix(filePath, trustedDir) {
-		return fmt.Errorf("file path is outside the trusted directory: %s", filePath)
+	prices := cfg.ModelPrices
+	if prices == nil {
+		prices = usage.DefaultPrices
 	}
 
-	dir := filepath.Dir(filePath)
+	opts := []internal.ClientOption{
+		internal.WithRetryPolicy(policy),
+		internal.WithUsageTracker(usage.NewTracker(ledger, cmd.Name(), prices, cfg.DailyBudgetUSD)),
+	}
 
-	// Create directory if it does not exist
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		if err := os.MkdirAll(dir, 0o750); err != nil {
-			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	if cfg.Provider == "azure" {
+		if cfg.AzureEndpoint == "" {
+			return nil, fmt.Errorf("provider azure requires azure_endpoint; set it with 'combo config set azure_endpoint https://<resource>.openai.azure.com'")
 		}
+		opts = append(opts, internal.WithAzure(internal.AzureConfig{
+			Endpoint:   cfg.AzureEndpoint,
+			Deployment: cfg.AzureDeployment,
+			APIVersion: cfg.AzureAPIVersion,
+			Bearer:     cfg.AzureAuth == "bearer",
+		}))
 	}
 
-	// Create file if it does not exist
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		file, err := os.Create(filePath)
+	noCache, err := cmd.Flags().GetBool("no-cache")
+	if err != nil {
+		return nil, err
+	}
+	if !noCache {
+		dir, err := cache.DefaultDir()
 		if err != nil {
-			return fmt.Errorf("failed to create config file %s: %w", filePath, err)
+			return nil, err
 		}
-		defer file.Close()
+		opts = append(opts, internal.WithCache(cache.New(dir, cfg.CacheTTL, int64(cfg.CacheMaxSizeMB)<<20)))
+	}
 
-		// Write default content to the file
-		if _, err := file.WriteString(defaultContent); err != nil {
-			return fmt.Errorf("failed to write to config file %s: %w", filePath, err)
+	return internal.NewOpenAIClient(apiKey, opts...), nil
+}
+
+// generateMessage sends the prompt and diff to the model and returns the generated text,
+// showing progress (including retries) while it waits.
+func generateMessage(client *internal.OpenAIClient, model, p, diff string, opts ...internal.RequestOption) (string, error) {
+	// Prepare the chat completion request
+	request := internal.CreateChatCompletionRequest(model, p, diff, opts...)
+
+	return runWithProgress(func(ctx context.Context) (string, error) {
+		// Send the chat completion request; the retry policy limits each attempt
+		response, err := client.SendChatCompletionRequest(ctx, request)
+		if err != nil {
+			return "", fmt.Errorf("chat completion request failed: %w", err)
 		}
-	}
 
-	return nil
+		// Ensure the chat completion response and message are valid
+		if len(response.Choices) == 0 || response.Choices[0].Message.Content == "" {
+			return "", fmt.Errorf("no message generated from the OpenAI response")
+		}
+
+		return response.Choices[0].Message.Content, nil
+	})
 }
 
-func getConfigDirectory() (string, error) {
-	dir, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get user home directory: %w", err)
+// generateCommitMessage asks the model for a commit message in the configured
+// output format and parses the answer into its fields. The types implied by
+// the paths of the changed files, if any, outrank the model's choice of type.
+func generateCommitMessage(client *internal.OpenAIClient, cfg *config.Config, p, diff string, pathTypes []string) (prompt.CommitMessage, error) {
+	var opts []internal.RequestOption
+	switch cfg.OutputFormat {
+	case prompt.JSON:
+		opts = append(opts, internal.WithJSONMode())
+	case prompt.JSONSchema:
+		opts = append(opts, internal.WithJSONSchema("commit_message", prompt.CommitMessageSchema))
 	}
-	return filepath.Join(dir, ".combo"), nil
-}
 
-// runGitCommit executes the git commit command
-func runGitCommit(message string) error {
-	cmd := exec.Command("git", "commit", "-m", message)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return generateValidMessage(client, cfg, p, diff, pathTypes, opts...)
 }
 
-// runGitBranch executes the git branch creation command
-func runGitBranch(name string) error {
-	cmd := exec.Command("git", "checkout", "-b", name)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+// generateValidMessage parses the model's answer into a commit message. An
+// answer that breaks the commit style, e.g. with an unknown type, is rejected
+// and asked for once more.
+func generateValidMessage(client *internal.OpenAIClient, cfg *config.Config, p, diff string, pathTypes []string, opts ...internal.RequestOption) (prompt.CommitMessage, error) {
+	var rejected error
+	for attempt := 0; attempt < 2; attempt++ {
+		request := p
+		if rejected != nil {
+			request = prompt.RetryPrompt(p, rejected)
+		}
+
+		content, err := generateMessage(client, cfg.Model, request, diff, opts...)
+		if err != nil {
+			return prompt.CommitMessage{}, err
+		}
+
+		msg := prompt.ParseCommitMessage(content, cfg.CommitStyle)
+		if len(pathTypes) > 0 && !slices.Contains(pathTypes, msg.Type) {
+			msg.Type = pathTypes[0]
+		}
+		if rejected = msg.Validate(cfg.CommitStyle); rejected == nil {
+			return msg, nil
+		}
+	}
+	return prompt.CommitMessage{}, fmt.Errorf("no valid message generated from the OpenAI response: %w", rejected)
 }
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tolgaOzen/combo/internal"
+	"github.com/tolgaOzen/combo/pkg/config"
+	"github.com/tolgaOzen/combo/pkg/prompt"
+)
+
+// chatRequest is what the answering client records of a request.
+type chatRequest struct {
+	Prompt    string // the system prompt
+	Input     string // the user message
+	MaxTokens int
+}
+
+// answeringClient returns a client whose server gives the answers in turn
+// and records every request.
+func answeringClient(t *testing.T, answers ...string) (*internal.OpenAIClient, *[]chatRequest) {
+	t.Helper()
+	var requests []chatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Messages  []struct{ Content string } `json:"messages"`
+			MaxTokens int                        `json:"max_tokens"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Error(err)
+		}
+		requests = append(requests, chatRequest{
+			Prompt:    request.Messages[0].Content,
+			Input:     request.Messages[len(request.Messages)-1].Content,
+			MaxTokens: request.MaxTokens,
+		})
+
+		answer := answers[min(len(requests), len(answers))-1]
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": answer}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	client := internal.NewOpenAIClient("secret",
+		internal.WithAzure(internal.AzureConfig{Endpoint: server.URL, Deployment: "d", APIVersion: "2024-10-21"}),
+		internal.WithRetryPolicy(internal.RetryPolicy{}))
+	return client, &requests
+}
+
+func TestGenerateCommitMessage(t *testing.T) {
+	cfg := &config.Config{Model: "gpt-4o-mini", CommitStyle: prompt.Conventional, OutputFormat: prompt.Text}
+
+	tests := []struct {
+		name        string
+		answers     []string
+		pathTypes   []string
+		want        string
+		wantErr     string
+		wantPrompts int
+	}{
+		{
+			name:        "valid answer",
+			answers:     []string{"feat: add login"},
+			want:        "feat: add login",
+			wantPrompts: 1,
+		},
+		{
+			name:        "unknown type is retried",
+			answers:     []string{"feature: add login", "feat: add login"},
+			want:        "feat: add login",
+			wantPrompts: 2,
+		},
+		{
+			name:        "missing type is retried",
+			answers:     []string{"add login", "feat(auth): add login"},
+			want:        "feat(auth): add login",
+			wantPrompts: 2,
+		},
+		{
+			name:        "path types replace an unknown type",
+			answers:     []string{"feature: describe the setup"},
+			pathTypes:   []string{"docs"},
+			want:        "docs: describe the setup",
+			wantPrompts: 1,
+		},
+		{
+			name:        "path types outrank the model",
+			answers:     []string{"feat: add tests"},
+			pathTypes:   []string{"test"},
+			want:        "test: add tests",
+			wantPrompts: 1,
+		},
+		{
+			name:        "rejected twice",
+			answers:     []string{"feature: add login"},
+			wantErr:     `unknown commit type "feature"`,
+			wantPrompts: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, requests := answeringClient(t, tt.answers...)
+
+			msg, err := generateCommitMessage(client, cfg, "Write a commit message.", "diff", tt.pathTypes)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("generateCommitMessage() = %v, want an error containing %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else if got := msg.Render(cfg.CommitStyle); got != tt.want {
+				t.Errorf("message = %q, want %q", got, tt.want)
+			}
+
+			if len(*requests) != tt.wantPrompts {
+				t.Fatalf("sent %d requests, want %d", len(*requests), tt.wantPrompts)
+			}
+			if tt.wantPrompts > 1 && !strings.Contains((*requests)[1].Prompt, "The previous answer was rejected") {
+				t.Errorf("retry prompt = %q, want the rejection reason", (*requests)[1].Prompt)
+			}
+		})
+	}
+}
@@ -1,13 +1,17 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
 	"os"
-	"path/filepath"
+	"os/exec"
+	"runtime"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/tolgaOzen/combo/pkg/config"
 )
 
 // NewConfigCommand - returns a new cobra command for config
@@ -20,6 +24,12 @@ func NewConfigCommand() *cobra.Command {
 	// Add subcommands
 	command.AddCommand(newConfigSetCommand())
 	command.AddCommand(newConfigGetCommand())
+	command.AddCommand(newConfigListCommand())
+	command.AddCommand(newConfigUnsetCommand())
+	command.AddCommand(newConfigEditCommand())
+	command.AddCommand(newConfigInitCommand())
+	command.AddCommand(newConfigValidateCommand())
+	command.AddCommand(newConfigEncryptKeyCommand())
 
 	return command
 }
@@ -34,26 +44,12 @@ func newConfigSetCommand() *cobra.Command {
 			key := args[0]
 			value := args[1]
 
-			configPath, err := getConfigPath()
-			if err != nil {
-				return err
-			}
-
-			// Load or create the config file
-			config, err := loadOrCreateConfig(configPath)
-			if err != nil {
-				return err
-			}
-
-			// Update the key-value pair
-			config[key] = value
-
-			// Save the updated config
-			if err := saveConfig(configPath, config); err != nil {
+			// Validate and save the key-value pair
+			if err := config.SetUserValue(config.Settings, key, value); err != nil {
 				return fmt.Errorf("failed to save configuration: %w", err)
 			}
 
-			fmt.Printf("Configuration set: %s=%s\n", key, value)
+			fmt.Printf("Configuration set: %s=%s\n", key, displayValue(key, value, false))
 			return nil
 		},
 	}
@@ -61,130 +57,287 @@ func newConfigSetCommand() *cobra.Command {
 
 // newConfigGetCommand - returns a cobra command for getting config values
 func newConfigGetCommand() *cobra.Command {
-	return &cobra.Command{
+	command := &cobra.Command{
 		Use:   "get [key]",
 		Short: "Get a configuration value by key",
 		Args:  cobra.ExactArgs(1), // Requires exactly 1 argument: key
 		RunE: func(cmd *cobra.Command, args []string) error {
 			key := args[0]
 
-			configPath, err := getConfigPath()
-			if err != nil {
-				return err
+			if _, ok := config.Settings.Lookup(key); !ok {
+				return config.Settings.Validate(key, "")
 			}
 
-			// Load the config
-			config, err := loadOrCreateConfig(configPath)
+			// Load the effective config
+			values, err := loadConfigValues(cmd)
 			if err != nil {
 				return err
 			}
 
 			// Get the value for the key
-			value, exists := config[key]
+			value, exists := values[key]
 			if !exists {
-				return fmt.Errorf("key %s not found in configuration", key)
+				return fmt.Errorf("key %s is not set", key)
+			}
+
+			reveal, err := cmd.Flags().GetBool("reveal")
+			if err != nil {
+				return err
 			}
 
-			fmt.Printf("%s=%s\n", key, value)
+			fmt.Printf("%s=%s\n", key, displayValue(key, value.Value, reveal))
 			return nil
 		},
 	}
+	command.Flags().Bool("reveal", false, "print secrets such as the API key in full")
+	return command
 }
 
-// getConfigPath - retrieves the configuration file path
-func getConfigPath() (string, error) {
-	dir, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get user home directory: %w", err)
+// newConfigListCommand - returns a cobra command for listing the effective config
+func newConfigListCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "list",
+		Short: "List the effective configuration",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			showOrigin, err := cmd.Flags().GetBool("show-origin")
+			if err != nil {
+				return err
+			}
+
+			reveal, err := cmd.Flags().GetBool("reveal")
+			if err != nil {
+				return err
+			}
+
+			// Load the effective config
+			values, err := loadConfigValues(cmd)
+			if err != nil {
+				return err
+			}
+
+			keys := make([]string, 0, len(values))
+			for key := range values {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				if showOrigin {
+					fmt.Printf("%s\t", values[key].Origin)
+				}
+				fmt.Printf("%s=%s\n", key, displayValue(key, values[key].Value, reveal))
+			}
+			return nil
+		},
 	}
-	return filepath.Join(dir, ".combo", "config"), nil
+	command.Flags().Bool("show-origin", false, "show where each value was set")
+	command.Flags().Bool("reveal", false, "print secrets such as the API key in full")
+	return command
 }
 
-// loadOrCreateConfig - loads the config file or creates a new one if it doesn't exist
-func loadOrCreateConfig(filePath string) (map[string]string, error) {
-	// Ensure the file path is within the trusted directory
-	trustedDir, err := getConfigPath()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get trusted directory: %w", err)
+// newConfigUnsetCommand - returns a cobra command for removing config values
+func newConfigUnsetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unset [key]",
+		Short: "Remove a configuration key from the user configuration",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key := args[0]
+
+			removed, err := config.UnsetUserValue(key)
+			if err != nil {
+				return fmt.Errorf("failed to save configuration: %w", err)
+			}
+			if !removed {
+				return fmt.Errorf("key %s is not set in the user configuration", key)
+			}
+
+			fmt.Printf("Configuration unset: %s\n", key)
+			return nil
+		},
 	}
+}
+
+// newConfigEditCommand - returns a cobra command for editing the config file
+func newConfigEditCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit",
+		Short: "Open the user configuration in $EDITOR",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.EnsureUserFile(); err != nil {
+				return fmt.Errorf("failed to ensure configuration: %w", err)
+			}
+
+			path, err := config.UserPath()
+			if err != nil {
+				return err
+			}
+
+			editor := os.Getenv("VISUAL")
+			if editor == "" {
+				editor = os.Getenv("EDITOR")
+			}
+			if editor == "" {
+				editor = "vi"
+				if runtime.GOOS == "windows" {
+					editor = "notepad"
+				}
+			}
+
+			// The editor may carry its own arguments, e.g. "code --wait"
+			parts := strings.Fields(editor)
+			editorCmd := exec.Command(parts[0], append(parts[1:], path)...)
+			editorCmd.Stdin = os.Stdin
+			editorCmd.Stdout = os.Stdout
+			editorCmd.Stderr = os.Stderr
+			if err := editorCmd.Run(); err != nil {
+				return fmt.Errorf("editor %s failed: %w", editor, err)
+			}
 
-	filePath = filepath.Clean(filePath)
-	if !strings.HasPrefix(filePath, trustedDir) {
-		return nil, fmt.Errorf("invalid file path: %s", filePath)
+			return reportConfigProblems(checkConfig(nil))
+		},
 	}
+}
+
+// newConfigValidateCommand - returns a cobra command for validating every config layer
+func newConfigValidateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Check every configuration source for unknown keys and invalid values",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			overrides, err := configOverrides(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := reportConfigProblems(checkConfig(overrides)); err != nil {
+				return err
+			}
 
-	// Ensure the directory exists
-	dir := filepath.Dir(filePath)
-	if err := os.MkdirAll(dir, 0o750); err != nil {
-		return nil, fmt.Errorf("failed to create config directory: %w", err)
+			fmt.Println("Configuration is valid.")
+			return nil
+		},
 	}
+}
+
+// newConfigEncryptKeyCommand - returns a cobra command for moving the API key into the encrypted credential file
+func newConfigEncryptKeyCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "encrypt-key",
+		Short: "Move the API key into a passphrase-encrypted credential file",
+		Long: `Encrypts the API key from ~/.combo/config (or one typed at the prompt) into
+~/.combo/credentials and removes it from the plaintext configuration. The passphrase
+is asked for whenever the key is needed, or read from COMBO_PASSPHRASE.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fd := int(os.Stdin.Fd())
+			if !term.IsTerminal(fd) {
+				return fmt.Errorf("encrypt-key must be run interactively")
+			}
+
+			path, err := config.UserPath()
+			if err != nil {
+				return err
+			}
+
+			values, err := config.ReadFile(path)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
 
-	// Initialize the config map
-	config := make(map[string]string)
+			apiKey := values["openai_api_key"]
+			if apiKey == "" {
+				if apiKey, err = readSecret(fd, "API key: "); err != nil {
+					return err
+				}
+			}
+			if apiKey == "" {
+				return fmt.Errorf("API key cannot be empty")
+			}
 
-	// Read the file if it exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		// File does not exist, return an empty config
-		return config, nil
+			passphrase, err := readSecret(fd, "New passphrase: ")
+			if err != nil {
+				return err
+			}
+			confirm, err := readSecret(fd, "Repeat passphrase: ")
+			if err != nil {
+				return err
+			}
+			if passphrase != confirm {
+				return fmt.Errorf("passphrases do not match")
+			}
+
+			if err := config.EncryptAPIKey(apiKey, passphrase); err != nil {
+				return err
+			}
+
+			if _, err := config.UnsetUserValue("openai_api_key"); err != nil {
+				return fmt.Errorf("failed to remove the plaintext key: %w", err)
+			}
+
+			credentials, err := config.CredentialsPath()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("API key encrypted to %s\n", credentials)
+			return nil
+		},
 	}
+}
 
-	file, err := os.Open(filePath)
+// readSecret asks for a value on the terminal without echoing it.
+func readSecret(fd int, label string) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	secret, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open config file: %w", err)
-	}
-	defer file.Close()
-
-	// Parse the file line by line
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		// Skip empty lines or comments
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid configuration line: %s", line)
-		}
-
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-		config[key] = value
+		return "", fmt.Errorf("failed to read input: %w", err)
 	}
+	return strings.TrimSpace(string(secret)), nil
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+// displayValue masks secret values unless reveal is set.
+func displayValue(key, value string, reveal bool) string {
+	if k, ok := config.Settings.Lookup(key); ok && k.Secret && !reveal {
+		return config.MaskSecret(value)
 	}
-
-	return config, nil
+	return value
 }
 
-// saveConfig - saves the configuration map to the file
-func saveConfig(filePath string, config map[string]string) error {
-	// Ensure the file path is within the trusted directory
-	trustedDir, err := getConfigPath()
-	if err != nil {
-		return fmt.Errorf("failed to get trusted directory: %w", err)
+// reportConfigProblems prints every problem and returns an error if there were any.
+func reportConfigProblems(problems []error) error {
+	for _, problem := range problems {
+		fmt.Printf("✘ %s\n", problem)
 	}
-
-	filePath = filepath.Clean(filePath)
-	if !strings.HasPrefix(filePath, trustedDir) {
-		return fmt.Errorf("invalid file path: %s", filePath)
+	if len(problems) > 0 {
+		return fmt.Errorf("configuration has %d problem(s)", len(problems))
 	}
+	return nil
+}
 
-	file, err := os.Create(filePath)
+// loadConfigValues resolves the raw layered configuration, applying any --set overrides.
+func loadConfigValues(cmd *cobra.Command) (map[string]config.Value, error) {
+	overrides, err := configOverrides(cmd)
 	if err != nil {
-		return fmt.Errorf("failed to create config file: %w", err)
+		return nil, err
 	}
-	defer file.Close()
-
-	for key, value := range config {
-		_, err := file.WriteString(fmt.Sprintf("%s=%s\n", key, value))
-		if err != nil {
-			return fmt.Errorf("failed to write to config file: %w", err)
-		}
+	layers, err := config.LoadLayers(overrides, repository)
+	if err != nil {
+		return nil, err
 	}
+	return layers.Values()
+}
 
-	return nil
+// checkConfig validates every configuration layer, applying any --set
+// overrides, and returns all problems found.
+func checkConfig(overrides []string) []error {
+	layers, err := config.LoadLayers(overrides, repository)
+	if err != nil {
+		return []error{err}
+	}
+	return layers.Check()
 }
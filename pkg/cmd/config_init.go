@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/tolgaOzen/combo/pkg/config"
+	"github.com/tolgaOzen/combo/pkg/prompt"
+)
+
+// suggestedModels are offered by the init wizard.
+var suggestedModels = []string{"gpt-4o-mini", "gpt-4o", "gpt-4.1-mini", "gpt-3.5-turbo"}
+
+// initStep is a single question of the init wizard
+type initStep struct {
+	key     string
+	title   string
+	choices []string
+	cursor  int
+}
+
+// Define the Bubble Tea model
+type configInitModel struct {
+	steps    []initStep
+	index    int
+	aborted  bool
+	quitting bool
+}
+
+// newConfigInitCommand - returns a cobra command for the interactive setup wizard
+func newConfigInitCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "init",
+		Short: "Interactively set up provider, model, locale and style",
+		Args:  cobra.NoArgs,
+		RunE:  configInit(),
+	}
+}
+
+// Init Initial model setup
+func (m configInitModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles user input and state changes
+func (m configInitModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		step := &m.steps[m.index]
+		switch msg.String() {
+		case "up", "k":
+			if step.cursor > 0 {
+				step.cursor--
+			}
+		case "down", "j":
+			if step.cursor < len(step.choices)-1 {
+				step.cursor++
+			}
+		case tea.KeyEnter.String():
+			if m.index == len(m.steps)-1 {
+				m.quitting = true
+				return m, tea.Quit
+			}
+			m.index++
+		case tea.KeyCtrlC.String(), tea.KeyEsc.String():
+			m.aborted = true
+			m.quitting = true
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+// View renders the current question
+func (m configInitModel) View() string {
+	if m.quitting {
+		if m.aborted {
+			return fmt.Sprintf(
+				"%s\n\n%s\n",
+				lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("9")).Render("✘ Setup aborted."),
+				lipgloss.NewStyle().Foreground(lipgloss.Color("7")).Italic(true).Render("Your configuration has not been changed."),
+			)
+		}
+		return ""
+	}
+
+	step := m.steps[m.index]
+
+	// Define styles
+	brandStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("7"))
+
+	headerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("6")).
+		Underline(true)
+
+	choiceStyle := lipgloss.NewStyle().
+		PaddingLeft(4)
+
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("2")).
+		Bold(true).
+		PaddingLeft(2)
+
+	promptStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("3")).
+		PaddingTop(1)
+
+	// Render sections
+	brand := brandStyle.Render(fmt.Sprintf("Setting up combo (%d/%d)", m.index+1, len(m.steps)))
+	header := headerStyle.Render(step.title)
+
+	var choices strings.Builder
+	for i, choice := range step.choices {
+		if i == step.cursor {
+			choices.WriteString(selectedStyle.Render("➤ "+choice) + "\n")
+		} else {
+			choices.WriteString(choiceStyle.Render(choice) + "\n")
+		}
+	}
+
+	prompt := promptStyle.Render("↑/↓ to move, enter to select, esc to abort")
+
+	// Combine output
+	return fmt.Sprintf("%s\n\n%s\n\n%s%s", brand, header, choices.String(), prompt)
+}
+
+// newInitStep builds a wizard question, placing the cursor on the current value.
+func newInitStep(key, title, current string, choices []string) initStep {
+	step := initStep{key: key, title: title, choices: choices}
+	for i, choice := range choices {
+		if choice == current {
+			step.cursor = i
+			return step
+		}
+	}
+	// Offer a custom current value as well
+	if current != "" {
+		step.choices = append([]string{current}, choices...)
+	}
+	return step
+}
+
+func configInit() func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		values, err := loadConfigValues(cmd)
+		if err != nil {
+			return err
+		}
+
+		providerKey, _ := config.Settings.Lookup("provider")
+		styleKey, _ := config.Settings.Lookup("commit_style")
+
+		locales := make([]string, 0, len(prompt.SupportedLocales()))
+		for _, locale := range prompt.SupportedLocales() {
+			locales = append(locales, locale.String())
+		}
+
+		steps := []initStep{
+			newInitStep("provider", "Which provider should generate your messages?", values["provider"].Value, providerKey.Choices),
+			newInitStep("model", "Which model should be used?", values["model"].Value, suggestedModels),
+			newInitStep("prompt_locale", "Which language should messages be written in?", values["prompt_locale"].Value, locales),
+			newInitStep("commit_style", "Which commit message style do you use?", values["commit_style"].Value, styleKey.Choices),
+		}
+
+		// Bubble Tea program setup
+		program := tea.NewProgram(configInitModel{steps: steps})
+		mod, err := program.Run()
+		if err != nil {
+			return fmt.Errorf("bubble tea program encountered an error: %w", err)
+		}
+
+		result, ok := mod.(configInitModel)
+		if !ok || result.aborted {
+			return nil
+		}
+
+		chosen := make(map[string]string, len(result.steps))
+		for _, step := range result.steps {
+			chosen[step.key] = step.choices[step.cursor]
+		}
+
+		if err := config.SetUserValues(config.Settings, chosen); err != nil {
+			return fmt.Errorf("failed to save configuration: %w", err)
+		}
+
+		path, err := config.UserPath()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("10")).Render("✔ Configuration saved to "+path))
+		if values["openai_api_key"].Value == "" {
+			fmt.Printf("%s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("7")).Italic(true).Render("Set your API key with: combo config set openai_api_key <key>"))
+		}
+		return nil
+	}
+}
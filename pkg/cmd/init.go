@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tolgaOzen/combo/pkg/config"
+	"github.com/tolgaOzen/combo/pkg/detect"
+	"github.com/tolgaOzen/combo/pkg/git"
+	"github.com/tolgaOzen/combo/pkg/prompt"
+)
+
+// NewInitCommand - returns a new cobra command for creating the repository configuration
+func NewInitCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "init",
+		Short: "Write a shared .combo/config for this repository",
+		Long: `Write a .combo/config for this repository with the commit style, locale and
+subject length, so that everyone working on it generates consistent messages.
+With --detect the settings are derived from the existing commit history.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			detectFlag, err := cmd.Flags().GetBool("detect")
+			if err != nil {
+				return err
+			}
+			dryRun, err := cmd.Flags().GetBool("dry-run")
+			if err != nil {
+				return err
+			}
+
+			var values map[string]string
+			if detectFlag {
+				conventions, err := detect.Detect()
+				if err != nil {
+					return err
+				}
+				printConventions(conventions)
+
+				values = conventions.Values()
+				if values == nil {
+					return fmt.Errorf("only %d usable commits found; at least %d are needed to detect the conventions", conventions.Commits, detect.MinCommits)
+				}
+			} else {
+				cfg, err := loadConfig(cmd)
+				if err != nil {
+					return err
+				}
+				values = effectiveRepoValues(cfg)
+			}
+
+			if dryRun {
+				keys := make([]string, 0, len(values))
+				for key := range values {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				for _, key := range keys {
+					fmt.Printf("%s=%s\n", key, values[key])
+				}
+				return nil
+			}
+
+			root, err := git.RepoRoot()
+			if err != nil {
+				return err
+			}
+			path, err := config.SetRepoValues(config.Settings, root, values)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Wrote %s; commit it to share these settings.\n", path)
+			return nil
+		},
+	}
+
+	command.Flags().Bool("detect", false, "derive the settings from the commit history")
+	command.Flags().Bool("dry-run", false, "print the settings instead of writing them")
+
+	return command
+}
+
+// effectiveRepoValues returns the current settings worth sharing with a repository.
+func effectiveRepoValues(cfg *config.Config) map[string]string {
+	style := string(cfg.CommitStyle)
+	if cfg.CommitStyle == prompt.Empty {
+		style = "plain"
+	}
+
+	values := map[string]string{
+		"commit_style":      style,
+		"prompt_locale":     cfg.PromptLocale.String(),
+		"prompt_max_length": strconv.Itoa(cfg.PromptMaxLength),
+	}
+	if len(cfg.CommitTypes) > 0 {
+		values["commit_types"] = strings.Join(cfg.CommitTypes, ",")
+	}
+	if len(cfg.CommitScopes) > 0 {
+		values["commit_scopes"] = strings.Join(cfg.CommitScopes, ",")
+	}
+	return values
+}
+
+// printConventions shows what was detected from the history.
+func printConventions(c detect.Conventions) {
+	style := string(c.Style)
+	if c.Style == prompt.Empty {
+		style = "plain"
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Commits analysed\t%d\n", c.Commits)
+	fmt.Fprintf(w, "Style\t%s\n", style)
+	if len(c.Types) > 0 {
+		fmt.Fprintf(w, "Types\t%s\n", strings.Join(c.Types, ", "))
+	}
+	if len(c.Scopes) > 0 {
+		fmt.Fprintf(w, "Scopes\t%s\n", strings.Join(c.Scopes, ", "))
+	}
+	if c.Commits > 0 {
+		fmt.Fprintf(w, "Subject length\t%d (90th percentile)\n", c.MaxLength)
+		fmt.Fprintf(w, "Language\t%s\n", c.Locale)
+	}
+	_ = w.Flush()
+	fmt.Println()
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/term"
+
+	"github.com/tolgaOzen/combo/internal"
+)
+
+// spinnerFrames are cycled while waiting for the model
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// errGenerationAborted is returned when the user cancels while waiting
+var errGenerationAborted = errors.New("generation aborted")
+
+type (
+	tickMsg   struct{}
+	retryMsg  internal.RetryEvent
+	resultMsg struct {
+		text string
+		err  error
+	}
+)
+
+// Define the Bubble Tea model
+type progressModel struct {
+	frame  int
+	retry  *internal.RetryEvent
+	result *resultMsg
+	cancel context.CancelFunc
+}
+
+// Init Initial model setup
+func (m progressModel) Init() tea.Cmd {
+	return tick()
+}
+
+// tick schedules the next spinner frame
+func tick() tea.Cmd {
+	return tea.Tick(100*time.Millisecond, func(time.Time) tea.Msg {
+		return tickMsg{}
+	})
+}
+
+// Update handles user input and state changes
+func (m progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tickMsg:
+		m.frame = (m.frame + 1) % len(spinnerFrames)
+		return m, tick()
+	case retryMsg:
+		event := internal.RetryEvent(msg)
+		m.retry = &event
+	case resultMsg:
+		m.result = &msg
+		return m, tea.Quit
+	case tea.KeyMsg:
+		switch msg.String() {
+		case tea.KeyCtrlC.String(), tea.KeyEsc.String():
+			m.cancel()
+			m.result = &resultMsg{err: errGenerationAborted}
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+// View renders the spinner and any retry in progress
+func (m progressModel) View() string {
+	if m.result != nil {
+		return ""
+	}
+
+	// Define styles
+	spinnerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("6"))
+
+	brandStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("7"))
+
+	retryStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("3")).
+		Italic(true).
+		PaddingLeft(2)
+
+	// Render sections
+	view := fmt.Sprintf("%s %s", spinnerStyle.Render(spinnerFrames[m.frame]), brandStyle.Render("Waiting for the model..."))
+	if m.retry != nil {
+		view += "\n" + retryStyle.Render(retryDescription(*m.retry))
+	}
+
+	return view + "\n"
+}
+
+// retryDescription summarises a retry for the user
+func retryDescription(event internal.RetryEvent) string {
+	return fmt.Sprintf("Retry %d/%d in %s: %v", event.Attempt, event.MaxRetries, event.Delay.Round(100*time.Millisecond), event.Err)
+}
+
+// runWithProgress runs fn while showing a spinner and retry progress. Without a
+// terminal, retries are reported on stderr instead.
+func runWithProgress(fn func(ctx context.Context) (string, error)) (string, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		ctx = internal.WithRetryObserver(ctx, func(event internal.RetryEvent) {
+			fmt.Fprintln(os.Stderr, retryDescription(event))
+		})
+		return fn(ctx)
+	}
+
+	program := tea.NewProgram(progressModel{cancel: cancel})
+	ctx = internal.WithRetryObserver(ctx, func(event internal.RetryEvent) {
+		program.Send(retryMsg(event))
+	})
+
+	go func() {
+		text, err := fn(ctx)
+		program.Send(resultMsg{text: text, err: err})
+	}()
+
+	mod, err := program.Run()
+	if err != nil {
+		return "", fmt.Errorf("bubble tea program encountered an error: %w", err)
+	}
+
+	result, ok := mod.(progressModel)
+	if !ok || result.result == nil {
+		return "", errGenerationAborted
+	}
+	return result.result.text, result.result.err
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tolgaOzen/combo/pkg/git"
+	"github.com/tolgaOzen/combo/pkg/prompt"
+)
+
+// NewPromptCommand - returns a new cobra command for inspecting prompt templates
+func NewPromptCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "prompt",
+		Short: "Inspect the prompts sent to the model",
+	}
+
+	// Add subcommands
+	command.AddCommand(newPromptShowCommand())
+
+	return command
+}
+
+// newPromptShowCommand - returns a cobra command for rendering the effective prompt
+func newPromptShowCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:       fmt.Sprintf("show <%s>", strings.Join(prompt.TemplateNames(), "|")),
+		Short:     "Render the effective prompt, including overrides from .combo/prompts",
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: prompt.TemplateNames(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			dirs, err := promptDirs()
+			if err != nil {
+				return err
+			}
+			source, err := prompt.TemplateSource(args[0], dirs)
+			if err != nil {
+				return err
+			}
+
+			var p string
+			switch args[0] {
+			case "commit":
+				issueKey, err := resolveIssue(cmd, cfg)
+				if err != nil {
+					return err
+				}
+				staged, err := git.StagedFiles()
+				if err != nil {
+					return err
+				}
+				p, err = commitPrompt(cfg, issueKey, staged)
+				if err != nil {
+					return err
+				}
+			case "branch":
+				p, err = branchPrompt(cfg)
+			case "squash":
+				p, err = squashPrompt(cfg)
+			}
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(os.Stderr, "# %s prompt from %s\n", args[0], source)
+			fmt.Print(p)
+			return nil
+		},
+	}
+
+	command.Flags().String("issue", "", "issue key to reference (defaults to the one found in the branch name)")
+
+	return command
+}
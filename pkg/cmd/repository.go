@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/tolgaOzen/combo/pkg/config"
+	"github.com/tolgaOzen/combo/pkg/detect"
+	"github.com/tolgaOzen/combo/pkg/git"
+)
+
+// repository opens the repository for the configuration loader and detects
+// its conventions.
+var repository = config.Repository{
+	Open:   openRepository,
+	Detect: detectConventions,
+}
+
+// openRepository opens the repository with the git_backend given by the
+// layers, so that the repository configuration and every later git call go
+// through it, and returns its root. Outside a repository the root is empty.
+func openRepository(layers *config.Layers) (string, error) {
+	value := git.ExecBackend.String()
+	origin := "default"
+	if v, ok := layers.Get("git_backend"); ok && v.Value != "" {
+		value, origin = v.Value, v.Origin
+	}
+
+	backend, err := git.ParseBackend(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid value for git_backend (from %s): %w", origin, err)
+	}
+	repo, err := git.Open(backend)
+	if err != nil {
+		// Outside a repository there is nothing to open; the current
+		// repository reports that once a command needs one
+		return "", nil
+	}
+	git.Use(repo)
+
+	root, err := git.RepoRoot()
+	if err != nil {
+		return "", nil
+	}
+	return root, nil
+}
+
+// detectConventions returns the conventions of the repository history as
+// configuration values.
+func detectConventions() (map[string]string, error) {
+	conventions, err := detect.Detect()
+	if err != nil {
+		return nil, err
+	}
+	return conventions.Values(), nil
+}
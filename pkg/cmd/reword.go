@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/tolgaOzen/combo/pkg/git"
+	"github.com/tolgaOzen/combo/pkg/prompt"
+)
+
+// rewordItem pairs an existing commit with its regenerated message
+type rewordItem struct {
+	commit   git.Commit
+	message  string
+	accepted bool
+}
+
+// Define the Bubble Tea model
+type rewordModel struct {
+	items    []rewordItem
+	index    int
+	aborted  bool
+	quitting bool
+}
+
+// NewRewordCommand - Creates new reword command
+func NewRewordCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "reword [rev]",
+		Short: "Regenerate messages for existing commits",
+		Example: `  combo reword HEAD
+  combo reword --range main..HEAD`,
+		RunE: reword(),
+		Args: cobra.MaximumNArgs(1),
+	}
+	command.Flags().String("range", "", "reword every commit in a revision range (e.g. main..HEAD)")
+	return command
+}
+
+// Init Initial model setup
+func (m rewordModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles user input and state changes
+func (m rewordModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "y", "Y", tea.KeyEnter.String():
+			m.items[m.index].accepted = true
+			return m.next()
+		case "n", "N":
+			m.items[m.index].accepted = false
+			return m.next()
+		case "q", tea.KeyCtrlC.String(), tea.KeyEsc.String():
+			m.aborted = true
+			m.quitting = true
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+// next advances to the following commit, quitting after the last one
+func (m rewordModel) next() (tea.Model, tea.Cmd) {
+	if m.index == len(m.items)-1 {
+		m.quitting = true
+		return m, tea.Quit
+	}
+	m.index++
+	return m, nil
+}
+
+// accepted returns the regenerated messages the user approved, keyed by commit hash
+func (m rewordModel) accepted() map[string]string {
+	messages := make(map[string]string)
+	for _, item := range m.items {
+		if item.accepted {
+			messages[item.commit.Hash] = item.message
+		}
+	}
+	return messages
+}
+
+// View renders the old and new messages side by side
+func (m rewordModel) View() string {
+	if m.quitting {
+		if m.aborted {
+			return fmt.Sprintf(
+				"%s\n\n%s\n",
+				lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("9")).Render("✘ Reword aborted."),
+				lipgloss.NewStyle().Foreground(lipgloss.Color("7")).Italic(true).Render("No commits have been rewritten."),
+			)
+		}
+		return fmt.Sprintf(
+			"%s\n",
+			lipgloss.NewStyle().Foreground(lipgloss.Color("7")).Italic(true).Render(
+				fmt.Sprintf("%d of %d messages accepted.", len(m.accepted()), len(m.items)),
+			),
+		)
+	}
+
+	item := m.items[m.index]
+
+	// Define styles
+	headerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("6")).
+		Underline(true)
+
+	columnStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Padding(0, 1).
+		Width(50)
+
+	oldStyle := columnStyle.
+		BorderForeground(lipgloss.Color("8")).
+		Foreground(lipgloss.Color("7"))
+
+	newStyle := columnStyle.
+		BorderForeground(lipgloss.Color("2")).
+		Foreground(lipgloss.Color("2")).
+		Italic(true)
+
+	labelStyle := lipgloss.NewStyle().
+		Bold(true)
+
+	promptStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("3")).
+		PaddingTop(1)
+
+	// Render sections
+	header := headerStyle.Render(fmt.Sprintf("Commit %d/%d (%s)", m.index+1, len(m.items), item.commit.Hash[:7]))
+	columns := lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		oldStyle.Render(labelStyle.Render("Current")+"\n"+item.commit.Message),
+		" ",
+		newStyle.Render(labelStyle.Render("Suggested")+"\n"+item.message),
+	)
+	prompt := promptStyle.Render("Use the suggested message? (Y/n, q to abort):")
+
+	// Combine output
+	return fmt.Sprintf("%s\n\n%s\n%s", header, columns, prompt)
+}
+
+func reword() func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		revRange, err := cmd.Flags().GetString("range")
+		if err != nil {
+			return err
+		}
+		if revRange == "" && len(args) == 0 {
+			return fmt.Errorf("specify a revision or a --range")
+		}
+		if revRange != "" && len(args) > 0 {
+			return fmt.Errorf("a revision and --range cannot be used together")
+		}
+		if revRange == "" {
+			revRange = args[0]
+		}
+
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		commits, err := git.ListCommits(revRange)
+		if err != nil {
+			return err
+		}
+		if len(commits) == 0 {
+			return fmt.Errorf("no commits found in %s", revRange)
+		}
+
+		// Merges cannot be rewritten, so refuse before generating any message
+		for _, c := range commits {
+			if c.Merge {
+				return fmt.Errorf("cannot reword merge commit %s", c.Hash[:7])
+			}
+		}
+
+		apiKey, err := cfg.APIKey(readPassphrase)
+		if err != nil {
+			return err
+		}
+
+		// Initialize the OpenAI client
+		client, err := newClient(cmd, cfg, apiKey)
+		if err != nil {
+			return err
+		}
+
+		// Generate a prompt
+		dirs, err := promptDirs()
+		if err != nil {
+			return err
+		}
+
+		p, err := prompt.GenerateCommitPrompt(
+			cfg.CommitStyle,
+			prompt.WithLocale(cfg.PromptLocale),
+			prompt.WithMaxLength(cfg.PromptMaxLength),
+			prompt.WithOutputFormat(cfg.OutputFormat),
+			prompt.WithCommitTypes(cfg.CommitTypes),
+			prompt.WithScopes(cfg.CommitScopes),
+			prompt.WithTemplateDirs(dirs...),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to generate prompt: %w", err)
+		}
+
+		items := make([]rewordItem, 0, len(commits))
+		for _, c := range commits {
+			diff, err := git.GetCommitDifferences(c.Hash)
+			if err != nil {
+				return err
+			}
+
+			generated, err := generateCommitMessage(client, cfg, p, diff, nil)
+			if err != nil {
+				return fmt.Errorf("failed to reword %s: %w", c.Hash[:7], err)
+			}
+
+			items = append(items, rewordItem{commit: c, message: generated.Render(cfg.CommitStyle)})
+		}
+
+		// Bubble Tea program setup
+		program := tea.NewProgram(rewordModel{items: items})
+		mod, err := program.Run()
+		if err != nil {
+			return fmt.Errorf("bubble tea program encountered an error: %w", err)
+		}
+
+		// Apply the accepted messages
+		if result, ok := mod.(rewordModel); ok && !result.aborted {
+			if err := git.RewordCommits(result.accepted()); err != nil {
+				return fmt.Errorf("failed to reword commits: %w", err)
+			}
+		}
+
+		return nil
+	}
+}
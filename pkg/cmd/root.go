@@ -2,15 +2,29 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
+
+	"github.com/tolgaOzen/combo/pkg/git"
 )
 
 // NewRootCommand - Creates new root command
 func NewRootCommand() *cobra.Command {
-	return &cobra.Command{
+	command := &cobra.Command{
 		Use:   "combo",
 		Short: "Generate commit messages based on git changes effortlessly.",
 		Long: `Combo is a CLI tool designed to generate concise and descriptive commit messages automatically. 
 It analyzes git changes and provides commit messages adhering to conventional commit standards or other formats of your choice. 
 Customize the language, length, and format to fit your workflow.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Every git call acts on the repository given with -C
+			dir, err := cmd.Flags().GetString("repo")
+			if err != nil || dir == "" {
+				return err
+			}
+			return git.SetWorkDir(dir)
+		},
 	}
+	command.PersistentFlags().StringP("repo", "C", "", "run as if combo was started in this directory")
+	command.PersistentFlags().StringArray("set", nil, "override a configuration value for this run (key=value, repeatable)")
+	command.PersistentFlags().Bool("no-cache", false, "always ask the model instead of reusing a cached response")
+	return command
 }
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tolgaOzen/combo/internal"
+	"github.com/tolgaOzen/combo/pkg/config"
+	"github.com/tolgaOzen/combo/pkg/git"
+	"github.com/tolgaOzen/combo/pkg/prompt"
+)
+
+// NewSquashMsgCommand - Creates new squash-msg command
+func NewSquashMsgCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "squash-msg [base]",
+		Short: "Generate a single commit message summarising the branch",
+		Long: `Reads every commit and the net diff between base and HEAD and prints one commit message
+that summarises the branch. Co-authored-by and issue reference trailers of the original
+commits are kept.`,
+		Example: `  combo squash-msg main
+  git commit -F <(combo squash-msg main)`,
+		RunE: squashMsg(),
+		Args: cobra.ExactArgs(1),
+	}
+}
+
+func squashMsg() func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		base := args[0]
+
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		apiKey, err := cfg.APIKey(readPassphrase)
+		if err != nil {
+			return err
+		}
+
+		commits, err := git.ListCommits(base + "..HEAD")
+		if err != nil {
+			return err
+		}
+		if len(commits) == 0 {
+			return fmt.Errorf("no commits between %s and HEAD", base)
+		}
+
+		diff, err := git.GetBranchDifferences(base)
+		if err != nil {
+			return err
+		}
+
+		// Initialize the OpenAI client
+		client, err := newClient(cmd, cfg, apiKey)
+		if err != nil {
+			return err
+		}
+
+		// Generate a prompt
+		p, err := squashPrompt(cfg)
+		if err != nil {
+			return err
+		}
+
+		message, err := generateSquashMessage(client, cfg, p, commits, diff)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(message)
+		return nil
+	}
+}
+
+// squashMaxTokens caps the squash message, which summarises a whole branch
+// and so needs more room than a single commit.
+const squashMaxTokens = 1000
+
+// generateSquashMessage asks the model for one message summarising the
+// commits and their net diff, checked against the commit style like any
+// other generated message, and keeps the trailers of the commits.
+func generateSquashMessage(client *internal.OpenAIClient, cfg *config.Config, p string, commits []git.Commit, diff string) (string, error) {
+	message, err := generateValidMessage(client, cfg, p, squashInput(commits, diff), nil, internal.WithMaxTokens(squashMaxTokens))
+	if err != nil {
+		return "", err
+	}
+	return appendTrailers(message.Render(cfg.CommitStyle), git.PreservedTrailers(commits)), nil
+}
+
+// squashInput gives the model the subjects of the commits, leaving out
+// merges, alongside the net diff.
+func squashInput(commits []git.Commit, diff string) string {
+	var input strings.Builder
+	input.WriteString("Commits on the branch:\n")
+	for _, c := range commits {
+		if c.Merge {
+			continue
+		}
+		fmt.Fprintf(&input, "- %s\n", c.Subject())
+	}
+	input.WriteString("\nNet diff:\n")
+	input.WriteString(diff)
+	return input.String()
+}
+
+// appendTrailers adds the trailers to message as a final paragraph, skipping any
+// the message already contains.
+func appendTrailers(message string, trailers []git.Trailer) string {
+	message = strings.TrimSpace(message)
+
+	var lines []string
+	for _, t := range trailers {
+		if !strings.Contains(message, t.String()) {
+			lines = append(lines, t.String())
+		}
+	}
+	if len(lines) == 0 {
+		return message
+	}
+
+	return message + "\n\n" + strings.Join(lines, "\n")
+}
+
+// squashPrompt renders the effective squash-merge prompt.
+func squashPrompt(cfg *config.Config) (string, error) {
+	dirs, err := promptDirs()
+	if err != nil {
+		return "", err
+	}
+
+	p, err := prompt.GenerateSquashPrompt(
+		cfg.CommitStyle,
+		prompt.WithLocale(cfg.PromptLocale),
+		prompt.WithMaxLength(cfg.PromptMaxLength),
+		prompt.WithCommitTypes(cfg.CommitTypes),
+		prompt.WithScopes(cfg.CommitScopes),
+		prompt.WithTemplateDirs(dirs...),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate prompt: %w", err)
+	}
+	return p, nil
+}
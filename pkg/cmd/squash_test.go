@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tolgaOzen/combo/internal"
+	"github.com/tolgaOzen/combo/pkg/config"
+	"github.com/tolgaOzen/combo/pkg/git"
+	"github.com/tolgaOzen/combo/pkg/prompt"
+)
+
+func TestGenerateSquashMessage(t *testing.T) {
+	cfg := &config.Config{Model: "gpt-4o-mini", CommitStyle: prompt.Conventional}
+
+	var commits []git.Commit
+	for i := 1; i <= 5; i++ {
+		commits = append(commits, git.Commit{Message: fmt.Sprintf("feat: step %d\n\nCo-authored-by: Dev %d <dev%d@example.com>", i, i, i)})
+	}
+	commits = append(commits, git.Commit{Message: "Merge branch 'main' into feature", Merge: true})
+
+	client, requests := answeringClient(t, "feat: add the feature\n\nDoes every step.")
+	got, err := generateSquashMessage(client, cfg, "Summarise the branch.", commits, "diff --git a/x b/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*requests) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(*requests))
+	}
+	request := (*requests)[0]
+	if request.MaxTokens != squashMaxTokens {
+		t.Errorf("max_tokens = %d, want %d", request.MaxTokens, squashMaxTokens)
+	}
+	for i := 1; i <= 5; i++ {
+		if !strings.Contains(request.Input, fmt.Sprintf("- feat: step %d\n", i)) {
+			t.Errorf("input is missing commit %d:\n%s", i, request.Input)
+		}
+	}
+	if strings.Contains(request.Input, "Merge branch") {
+		t.Errorf("input lists the merge commit:\n%s", request.Input)
+	}
+	if !strings.HasSuffix(request.Input, "\nNet diff:\ndiff --git a/x b/x") {
+		t.Errorf("input does not end with the diff:\n%s", request.Input)
+	}
+
+	if !strings.HasPrefix(got, "feat: add the feature\n\nDoes every step.\n\n") {
+		t.Errorf("message = %q", got)
+	}
+	for i := 1; i <= 5; i++ {
+		if !strings.Contains(got, fmt.Sprintf("Co-authored-by: Dev %d <dev%d@example.com>", i, i)) {
+			t.Errorf("message is missing co-author %d:\n%s", i, got)
+		}
+	}
+}
+
+func TestGenerateSquashMessageValidates(t *testing.T) {
+	cfg := &config.Config{Model: "gpt-4o-mini", CommitStyle: prompt.Conventional}
+	commits := []git.Commit{{Message: "feat: step 1\n\nRefs: #12"}}
+
+	client, requests := answeringClient(t,
+		"wip: lots of things",
+		"```\nFeat(api): add the endpoint\n\nServes the feature.\n```",
+	)
+	got, err := generateSquashMessage(client, cfg, "Summarise the branch.", commits, "diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*requests) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(*requests))
+	}
+	if !strings.Contains((*requests)[1].Prompt, `unknown commit type "wip"`) {
+		t.Errorf("retry prompt does not give the reason:\n%s", (*requests)[1].Prompt)
+	}
+	if want := "feat(api): add the endpoint\n\nServes the feature.\n\nRefs: #12"; got != want {
+		t.Errorf("message = %q, want %q", got, want)
+	}
+
+	client, _ = answeringClient(t, "wip: one", "wip: two")
+	if _, err := generateSquashMessage(client, cfg, "Summarise the branch.", commits, "diff"); err == nil {
+		t.Error("an invalid answer was accepted")
+	}
+}
+
+func TestGenerateMessageDefaultMaxTokens(t *testing.T) {
+	client, requests := answeringClient(t, "fix: handle nil")
+	if _, err := generateMessage(client, "gpt-4o-mini", "Write a commit message.", "diff"); err != nil {
+		t.Fatal(err)
+	}
+	if got := (*requests)[0].MaxTokens; got != internal.DefaultMaxTokens {
+		t.Errorf("max_tokens = %d, want %d", got, internal.DefaultMaxTokens)
+	}
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tolgaOzen/combo/pkg/usage"
+)
+
+// NewUsageCommand - returns a new cobra command for reporting token usage and cost
+func NewUsageCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "usage",
+		Short: "Report token usage and estimated cost by day, command and model",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			days, err := cmd.Flags().GetInt("days")
+			if err != nil {
+				return err
+			}
+
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			path, err := usage.DefaultPath()
+			if err != nil {
+				return err
+			}
+			records, err := usage.Load(path)
+			if err != nil {
+				return err
+			}
+
+			// Keep the records of the last n days, today included
+			if days > 0 {
+				now := time.Now()
+				since := time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, time.Local)
+				kept := records[:0]
+				for _, r := range records {
+					if !r.Time.Before(since) {
+						kept = append(kept, r)
+					}
+				}
+				records = kept
+			}
+
+			if len(records) == 0 {
+				fmt.Println("No usage recorded yet.")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			printUsageGroup(w, "DAY", usage.GroupBy(records, func(r usage.Record) string {
+				return r.Time.Local().Format(time.DateOnly)
+			}))
+			printUsageGroup(w, "COMMAND", usage.GroupBy(records, func(r usage.Record) string { return r.Command }))
+			printUsageGroup(w, "MODEL", usage.GroupBy(records, func(r usage.Record) string { return r.Model }))
+			printUsageGroup(w, "TOTAL", usage.GroupBy(records, func(usage.Record) string { return "all" }))
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			if cfg.DailyBudgetUSD > 0 {
+				today := usage.GroupBy(records, func(r usage.Record) string {
+					return r.Time.Local().Format(time.DateOnly)
+				})[time.Now().Format(time.DateOnly)]
+				spent := 0.0
+				if today != nil {
+					spent = today.CostUSD
+				}
+				fmt.Printf("\nToday: $%.4f of the $%.2f daily budget\n", spent, cfg.DailyBudgetUSD)
+			}
+
+			return nil
+		},
+	}
+
+	command.Flags().Int("days", 30, "number of days to report, 0 for all recorded usage")
+
+	return command
+}
+
+// printUsageGroup writes one table section with a row per group, sorted by name.
+func printUsageGroup(w *tabwriter.Writer, title string, groups map[string]*usage.Totals) {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "%s\tCALLS\tPROMPT\tCOMPLETION\tCOST (USD)\n", title)
+	for _, name := range names {
+		t := groups[name]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.4f\n", name, t.Calls, t.PromptTokens, t.CompletionTokens, t.CostUSD)
+	}
+	fmt.Fprintln(w, "\t\t\t\t")
+}
@@ -0,0 +1,280 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SystemPath holds machine-wide settings shared by every user.
+const SystemPath = "/etc/combo/config"
+
+// systemPath is the system configuration read by ReadLayers.
+var systemPath = SystemPath
+
+// EnvPrefix is the prefix of environment variables that override configuration
+// keys, e.g. COMBO_PROMPT_LOCALE for prompt_locale.
+const EnvPrefix = "COMBO_"
+
+// envAliases are well-known environment variables mapped onto configuration
+// keys. They rank below the COMBO_<KEY> form, in the order listed.
+var envAliases = []struct{ name, key string }{
+	{"OPENAI_API_KEY", "openai_api_key"},
+	{"COMBO_API_KEY", "openai_api_key"},
+	{"AZURE_OPENAI_ENDPOINT", "azure_endpoint"},
+}
+
+// Value is a raw configuration value together with the source that set it.
+type Value struct {
+	Value  string
+	Origin string
+}
+
+// layer is one source of raw configuration values.
+type layer struct {
+	origin   string
+	values   map[string]string
+	repo     bool // set by the repository, so UserOnly keys are refused
+	override bool // an environment variable or --set, ranking above the repository
+}
+
+// Layers holds the raw values of every configuration source. Later layers
+// override earlier ones:
+//
+//  1. built-in defaults
+//  2. /etc/combo/config
+//  3. ~/.combo/config
+//  4. the repository's .combo/config, or .combo.yaml, or else the conventions
+//     detected from history
+//  5. COMBO_* environment variables
+//  6. command-line overrides
+//
+// Reading the layers has no side effects; the caller opens the repository
+// and detects the conventions, see Repository.
+type Layers struct {
+	schema Schema
+	layers []layer
+}
+
+// Repository lets Load read the repository configuration without opening the
+// repository itself, which is left to the commands.
+type Repository struct {
+	// Open opens the repository as configured by the layers read so far and
+	// returns its root, or "" outside a repository.
+	Open func(layers *Layers) (root string, err error)
+	// Detect returns the conventions detected from the history of the
+	// repository as configuration values. It is called when the repository
+	// has no configuration and auto_detect is on.
+	Detect func() (map[string]string, error)
+}
+
+// Load resolves and validates the configuration of every layer. Overrides are
+// "key=value" pairs given on the command line.
+func Load(overrides []string, repo Repository) (*Config, error) {
+	layers, err := LoadLayers(overrides, repo)
+	if err != nil {
+		return nil, err
+	}
+	values, err := layers.Values()
+	if err != nil {
+		return nil, err
+	}
+	return FromValues(values)
+}
+
+// LoadLayers reads every configuration layer of the combo settings: the
+// system, user, environment and override layers, then the repository
+// configuration, or the conventions detected from history when it has none.
+func LoadLayers(overrides []string, repo Repository) (*Layers, error) {
+	layers, err := ReadLayers(Settings, overrides)
+	if err != nil {
+		return nil, err
+	}
+	if repo.Open == nil {
+		return layers, nil
+	}
+
+	root, err := repo.Open(layers)
+	if err != nil {
+		return nil, err
+	}
+	if root == "" {
+		return layers, nil
+	}
+	found, err := layers.AddRepo(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if v, _ := layers.Get("auto_detect"); !found && repo.Detect != nil {
+		if enabled, _ := parseBool(v.Value); enabled {
+			values, err := repo.Detect()
+			if err != nil {
+				return nil, err
+			}
+			layers.AddDetected(values)
+		}
+	}
+	return layers, nil
+}
+
+// ReadLayers reads the system and user configuration, the environment and
+// the command-line overrides, given as "key=value" pairs. The repository
+// layer is added with AddRepo, since where the repository is may depend on
+// these layers.
+func ReadLayers(schema Schema, overrides []string) (*Layers, error) {
+	l := &Layers{schema: schema}
+
+	// System configuration
+	system, err := readFileIfExists(systemPath)
+	if err != nil {
+		return nil, err
+	}
+	l.layers = append(l.layers, layer{origin: systemPath, values: system})
+
+	// User configuration
+	userPath, err := UserPath()
+	if err != nil {
+		return nil, err
+	}
+	user, err := readFileIfExists(userPath)
+	if err != nil {
+		return nil, err
+	}
+	l.layers = append(l.layers, layer{origin: userPath, values: user})
+
+	// Environment variables; unknown COMBO_* variables are left alone
+	for _, alias := range envAliases {
+		if value := os.Getenv(alias.name); value != "" {
+			l.layers = append(l.layers, layer{origin: "env:" + alias.name, values: map[string]string{alias.key: value}, override: true})
+		}
+	}
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+		if !strings.HasPrefix(name, EnvPrefix) {
+			continue
+		}
+		key := strings.ToLower(strings.TrimPrefix(name, EnvPrefix))
+		if _, ok := schema.Lookup(key); ok {
+			l.layers = append(l.layers, layer{origin: "env:" + name, values: map[string]string{key: value}, override: true})
+		}
+	}
+
+	// Command-line overrides
+	flags := make(map[string]string)
+	for _, override := range overrides {
+		key, value, ok := strings.Cut(override, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid --set value %q, expected key=value", override)
+		}
+		flags[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	l.layers = append(l.layers, layer{origin: "flag:--set", values: flags, override: true})
+
+	return l, nil
+}
+
+// AddRepo reads .combo/config, or failing that .combo.yaml, from the
+// repository root and reports whether either exists.
+func (l *Layers) AddRepo(root string) (bool, error) {
+	path := filepath.Join(root, ".combo", "config")
+	values, err := readFileIfExists(path)
+	if err != nil {
+		return false, err
+	}
+	if values == nil {
+		path = filepath.Join(root, ".combo.yaml")
+		if values, err = readYAMLFileIfExists(path); err != nil {
+			return false, err
+		}
+	}
+
+	l.insert(layer{origin: path, values: values, repo: true})
+	return values != nil, nil
+}
+
+// AddDetected adds the conventions detected from history, which stand in for
+// a missing repository configuration and rank with it.
+func (l *Layers) AddDetected(values map[string]string) {
+	if values != nil {
+		l.insert(layer{origin: "detected", values: values})
+	}
+}
+
+// insert places a layer below the environment variables and command-line overrides.
+func (l *Layers) insert(n layer) {
+	i := len(l.layers)
+	for i > 0 && l.layers[i-1].override {
+		i--
+	}
+	l.layers = append(l.layers[:i], append([]layer{n}, l.layers[i:]...)...)
+}
+
+// Get returns the value of the highest-ranking layer that sets the key,
+// ignoring the built-in default.
+func (l *Layers) Get(name string) (Value, bool) {
+	for i := len(l.layers) - 1; i >= 0; i-- {
+		if value, ok := l.layers[i].values[name]; ok {
+			return Value{Value: value, Origin: l.layers[i].origin}, true
+		}
+	}
+	return Value{}, false
+}
+
+// Values resolves the raw value of every key set by a layer or with a default.
+func (l *Layers) Values() (map[string]Value, error) {
+	values := make(map[string]Value)
+	for _, key := range l.schema {
+		if key.Default != "" {
+			values[key.Name] = Value{Value: key.Default, Origin: "default"}
+		}
+	}
+
+	for _, n := range l.layers {
+		for name, value := range n.values {
+			if err := l.checkKey(n, name); err != nil {
+				return nil, err
+			}
+			values[name] = Value{Value: value, Origin: n.origin}
+		}
+	}
+
+	return values, nil
+}
+
+// Check validates every key and value of every layer and returns all problems found.
+func (l *Layers) Check() []error {
+	var errs []error
+	for _, n := range l.layers {
+		names := make([]string, 0, len(n.values))
+		for name := range n.values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if err := l.checkKey(n, name); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if err := l.schema.Validate(name, n.values[name]); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", n.origin, err))
+			}
+		}
+	}
+	return errs
+}
+
+// checkKey reports unknown keys and user-only keys set by a repository.
+func (l *Layers) checkKey(n layer, name string) error {
+	key, ok := l.schema.Lookup(name)
+	if !ok {
+		return fmt.Errorf("%s: %w", n.origin, l.schema.unknownKeyError(name))
+	}
+	if n.repo && key.UserOnly {
+		return fmt.Errorf("%s cannot be set in the repository configuration %s", name, n.origin)
+	}
+	return nil
+}
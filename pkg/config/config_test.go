@@ -0,0 +1,264 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testSchema = Schema{
+	{Name: "k1"}, {Name: "k2"}, {Name: "k3"}, {Name: "k4"}, {Name: "k5"},
+	{Name: "k6", Default: "default"},
+	{Name: "secret", UserOnly: true},
+	{Name: "number", Validate: func(value string) error {
+		if strings.Trim(value, "0123456789") != "" {
+			return fmt.Errorf("expected a number, got %q", value)
+		}
+		return nil
+	}},
+}
+
+// testLayout points the system and user configuration at files in temporary
+// directories and returns their paths and a repository root.
+func testLayout(t *testing.T, system, user string) (systemFile, userFile, root string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	systemFile = filepath.Join(dir, "system")
+	writeFile(t, systemFile, system)
+	previous := systemPath
+	systemPath = systemFile
+	t.Cleanup(func() { systemPath = previous })
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	userFile = filepath.Join(home, ".combo", "config")
+	writeFile(t, userFile, user)
+
+	for _, alias := range envAliases {
+		t.Setenv(alias.name, "")
+	}
+	return systemFile, userFile, t.TempDir()
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLayerPrecedence(t *testing.T) {
+	systemFile, userFile, root := testLayout(t,
+		"k1=system\nk2=system\n",
+		"k2=user\nk3=user\n")
+	repoFile := filepath.Join(root, ".combo", "config")
+	writeFile(t, repoFile, "k3=repo\nk4=repo\n")
+	t.Setenv("COMBO_K4", "env")
+	t.Setenv("COMBO_K5", "env")
+
+	layers, err := ReadLayers(testSchema, []string{"k5=flag"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := layers.AddRepo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Fatal("AddRepo did not find .combo/config")
+	}
+
+	values, err := layers.Values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Value{
+		"k1": {"system", systemFile},
+		"k2": {"user", userFile},
+		"k3": {"repo", repoFile},
+		"k4": {"env", "env:COMBO_K4"},
+		"k5": {"flag", "flag:--set"},
+		"k6": {"default", "default"},
+	}
+	for key, w := range want {
+		if got := values[key]; got != w {
+			t.Errorf("%s = %+v, want %+v", key, got, w)
+		}
+	}
+	if len(values) != len(want) {
+		t.Errorf("got %d values, want %d: %v", len(values), len(want), values)
+	}
+
+	if got, ok := layers.Get("k4"); !ok || got.Value != "env" {
+		t.Errorf("Get(k4) = %+v, %v", got, ok)
+	}
+	if _, ok := layers.Get("k6"); ok {
+		t.Error("Get returned the built-in default")
+	}
+}
+
+func TestDetectedLayer(t *testing.T) {
+	_, _, root := testLayout(t, "", "k1=user\nk2=user\n")
+	t.Setenv("COMBO_K2", "env")
+
+	layers, err := ReadLayers(testSchema, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := layers.AddRepo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Fatal("AddRepo found a configuration in an empty repository")
+	}
+	layers.AddDetected(map[string]string{"k1": "detected", "k2": "detected", "k3": "detected"})
+
+	values, err := layers.Values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Value{
+		"k1": {"detected", "detected"},
+		"k2": {"env", "env:COMBO_K2"},
+		"k3": {"detected", "detected"},
+	}
+	for key, w := range want {
+		if got := values[key]; got != w {
+			t.Errorf("%s = %+v, want %+v", key, got, w)
+		}
+	}
+}
+
+func TestRepoYAML(t *testing.T) {
+	_, _, root := testLayout(t, "", "")
+	writeFile(t, filepath.Join(root, ".combo.yaml"), "k1: yaml\nnumber: 3\n")
+
+	layers, err := ReadLayers(testSchema, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found, err := layers.AddRepo(root); err != nil || !found {
+		t.Fatalf("AddRepo = %v, %v", found, err)
+	}
+
+	values, err := layers.Values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["k1"].Value != "yaml" || values["number"].Value != "3" {
+		t.Errorf("values = %v", values)
+	}
+}
+
+func TestUserOnlyKeys(t *testing.T) {
+	_, _, root := testLayout(t, "", "secret=from-user\n")
+
+	// The user configuration and --set may set user-only keys
+	layers, err := ReadLayers(testSchema, []string{"secret=from-flag"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := layers.Values(); err != nil {
+		t.Errorf("user-only key outside the repository: %v", err)
+	}
+	if problems := layers.Check(); len(problems) != 0 {
+		t.Errorf("Check() = %v", problems)
+	}
+
+	// The repository configuration may not
+	repoFile := filepath.Join(root, ".combo", "config")
+	writeFile(t, repoFile, "secret=from-repo\nnumber=x\n")
+	if _, err := layers.AddRepo(root); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = layers.Values()
+	if err == nil || !strings.Contains(err.Error(), "secret cannot be set in the repository configuration "+repoFile) {
+		t.Errorf("Values() = %v, want the user-only error", err)
+	}
+
+	problems := layers.Check()
+	if len(problems) != 2 {
+		t.Fatalf("Check() = %v, want 2 problems", problems)
+	}
+	if !strings.Contains(problems[0].Error(), "invalid value for number") {
+		t.Errorf("first problem = %v", problems[0])
+	}
+	if !strings.Contains(problems[1].Error(), "secret cannot be set") {
+		t.Errorf("second problem = %v", problems[1])
+	}
+}
+
+func TestUnknownKeys(t *testing.T) {
+	_, userFile, _ := testLayout(t, "", "k7=x\n")
+
+	layers, err := ReadLayers(testSchema, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = layers.Values()
+	want := userFile + `: unknown configuration key "k7" (did you mean "k1"?)`
+	if err == nil || err.Error() != want {
+		t.Errorf("Values() = %v, want %q", err, want)
+	}
+}
+
+func TestReadLayersInvalidOverride(t *testing.T) {
+	testLayout(t, "", "")
+	if _, err := ReadLayers(testSchema, []string{"k1"}); err == nil {
+		t.Error("ReadLayers accepted an override without a value")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	_, _, root := testLayout(t, "", "auto_detect=true\nmodel=gpt-4o\n")
+
+	detected := 0
+	repo := Repository{
+		Open: func(layers *Layers) (string, error) {
+			// The repository is opened as configured by the layers read so far
+			if v, _ := layers.Get("model"); v.Value != "gpt-4o" {
+				t.Errorf("Open saw model %q", v.Value)
+			}
+			return root, nil
+		},
+		Detect: func() (map[string]string, error) {
+			detected++
+			return map[string]string{"commit_style": "gitmoji", "prompt_max_length": "50"}, nil
+		},
+	}
+
+	cfg, err := Load([]string{"prompt_max_length=60"}, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detected != 1 || cfg.Model != "gpt-4o" || cfg.CommitStyle != "gitmoji" || cfg.PromptMaxLength != 60 {
+		t.Errorf("Load() = %+v after %d detections", cfg, detected)
+	}
+
+	// A repository configuration replaces detection
+	writeFile(t, filepath.Join(root, ".combo", "config"), "commit_style=plain\n")
+	cfg, err = Load(nil, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detected != 1 || cfg.CommitStyle != "" {
+		t.Errorf("Load() = %+v after %d detections", cfg, detected)
+	}
+
+	// Outside a repository only the other layers apply
+	cfg, err = Load(nil, Repository{Open: func(*Layers) (string, error) { return "", nil }, Detect: repo.Detect})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detected != 1 || cfg.CommitStyle != "conventional" || cfg.Model != "gpt-4o" {
+		t.Errorf("Load() = %+v after %d detections", cfg, detected)
+	}
+}
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// scrypt parameters for deriving the credential file key from a passphrase.
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+// ErrNoAPIKey is returned when no source provides an API key.
+var ErrNoAPIKey = errors.New("missing or empty 'openai_api_key' in configuration")
+
+// PassphraseFunc asks the user for the passphrase of the credential file.
+type PassphraseFunc func() (string, error)
+
+// credentialFile is the on-disk format of ~/.combo/credentials.
+type credentialFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// CredentialsPath returns the path of the encrypted credential file, ~/.combo/credentials.
+func CredentialsPath() (string, error) {
+	dir, err := UserDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "credentials"), nil
+}
+
+// APIKey resolves the API key. In order, it uses the openai_api_key value
+// (which may come from OPENAI_API_KEY or COMBO_API_KEY), the output of
+// api_key_command, and the encrypted credential file, asking for its
+// passphrase only when it is needed.
+func APIKey(key, command string, passphrase PassphraseFunc) (string, error) {
+	if key != "" {
+		return key, nil
+	}
+
+	if command != "" {
+		return runAPIKeyCommand(command)
+	}
+
+	path, err := CredentialsPath()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return DecryptAPIKey(passphrase)
+	}
+
+	return "", ErrNoAPIKey
+}
+
+// runAPIKeyCommand runs the configured command through the shell and returns its trimmed output.
+func runAPIKeyCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("api_key_command failed: %w", err)
+	}
+
+	key := strings.TrimSpace(string(out))
+	if key == "" {
+		return "", fmt.Errorf("api_key_command printed no key")
+	}
+	return key, nil
+}
+
+// EncryptAPIKey stores the key in ~/.combo/credentials, encrypted with AES-GCM
+// under a key derived from the passphrase with scrypt.
+func EncryptAPIKey(apiKey, passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("passphrase cannot be empty")
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	gcm, err := newCredentialCipher(passphrase, salt, scryptN, scryptR, scryptP)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	data, err := json.MarshalIndent(credentialFile{
+		Version:    1,
+		KDF:        "scrypt",
+		N:          scryptN,
+		R:          scryptR,
+		P:          scryptP,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, []byte(apiKey), nil),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials: %w", err)
+	}
+
+	path, err := CredentialsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+
+	return enforcePrivate(path)
+}
+
+// DecryptAPIKey reads the key from ~/.combo/credentials.
+func DecryptAPIKey(passphrase PassphraseFunc) (string, error) {
+	path, err := CredentialsPath()
+	if err != nil {
+		return "", err
+	}
+
+	if err := enforcePrivate(path); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return "", fmt.Errorf("failed to read credentials: %w", err)
+	}
+
+	var file credentialFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return "", fmt.Errorf("failed to parse credentials %s: %w", path, err)
+	}
+	if file.Version != 1 || file.KDF != "scrypt" {
+		return "", fmt.Errorf("unsupported credential file format in %s", path)
+	}
+
+	if passphrase == nil {
+		return "", fmt.Errorf("the API key is encrypted; set COMBO_PASSPHRASE or run interactively")
+	}
+	secret, err := passphrase()
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := newCredentialCipher(secret, file.Salt, file.N, file.R, file.P)
+	if err != nil {
+		return "", err
+	}
+
+	plain, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt credentials: wrong passphrase?")
+	}
+
+	return string(plain), nil
+}
+
+// newCredentialCipher derives the AES-GCM cipher for the credential file.
+func newCredentialCipher(passphrase string, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, n, r, p, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// MaskSecret hides all but the first three and last four characters of a secret.
+func MaskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	if len(value) <= 12 {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:3] + strings.Repeat("*", 8) + value[len(value)-4:]
+}
+
+// enforcePrivate makes sure a file holding secrets is only accessible by its
+// owner, tightening the permissions to 0600 with a warning if needed.
+func enforcePrivate(path string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if info.Mode().Perm()&0o077 == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "warning: %s was accessible by other users (mode %04o); changing it to 0600\n", path, info.Mode().Perm())
+	if err := os.Chmod(path, 0o600); err != nil {
+		return fmt.Errorf("failed to restrict permissions of %s: %w", path, err)
+	}
+	return nil
+}
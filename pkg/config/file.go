@@ -0,0 +1,334 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultContent is written to a fresh user configuration file.
+const DefaultContent = `# Default configuration
+openai_api_key=
+prompt_locale=en-US
+prompt_max_length=72
+`
+
+// UserDir returns the directory holding the user's configuration, ~/.combo.
+func UserDir() (string, error) {
+	dir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(dir, ".combo"), nil
+}
+
+// UserPath returns the path of the user's configuration file, ~/.combo/config.
+func UserPath() (string, error) {
+	dir, err := UserDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config"), nil
+}
+
+// EnsureUserFile creates ~/.combo/config with the default content if it does
+// not exist, and makes sure only its owner can read it.
+func EnsureUserFile() error {
+	path, err := UserPath()
+	if err != nil {
+		return err
+	}
+
+	// Create directory if it does not exist
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(path), err)
+	}
+
+	// Create file if it does not exist; it may hold the API key
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to create config file %s: %w", path, err)
+		}
+		defer file.Close()
+
+		// Write default content to the file
+		if _, err := file.WriteString(DefaultContent); err != nil {
+			return fmt.Errorf("failed to write to config file %s: %w", path, err)
+		}
+	}
+
+	return enforcePrivate(path)
+}
+
+// SetUserValue validates a value against the schema and stores it in ~/.combo/config.
+func SetUserValue(schema Schema, key, value string) error {
+	return SetUserValues(schema, map[string]string{key: value})
+}
+
+// SetUserValues validates the values against the schema and stores them in
+// ~/.combo/config. Keys the file does not have yet are appended in alphabetical order.
+func SetUserValues(schema Schema, values map[string]string) error {
+	keys := make([]string, 0, len(values))
+	for key, value := range values {
+		if err := schema.Validate(key, value); err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	file, err := openUserFile()
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		file.Set(key, values[key])
+	}
+	return file.Save()
+}
+
+// UnsetUserValue removes a key from ~/.combo/config, reporting whether it was present.
+// Unknown keys can be removed too, so that typos can be cleaned up.
+func UnsetUserValue(key string) (bool, error) {
+	file, err := openUserFile()
+	if err != nil {
+		return false, err
+	}
+
+	if !file.Unset(key) {
+		return false, nil
+	}
+	return true, file.Save()
+}
+
+// RepoPath returns the path of the configuration file of the repository at root, <root>/.combo/config.
+func RepoPath(root string) string {
+	return filepath.Join(root, ".combo", "config")
+}
+
+// SetRepoValues validates the values against the schema and stores them in
+// the .combo/config of the repository at root, creating it if needed, and
+// returns the path of the file. The file is meant to be committed, so
+// user-only keys are refused.
+func SetRepoValues(schema Schema, root string, values map[string]string) (string, error) {
+	keys := make([]string, 0, len(values))
+	for key, value := range values {
+		if err := schema.Validate(key, value); err != nil {
+			return "", err
+		}
+		if k, _ := schema.Lookup(key); k.UserOnly {
+			return "", fmt.Errorf("%s cannot be set in the repository configuration", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	path := RepoPath(root)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", filepath.Dir(path), err)
+	}
+
+	file, err := OpenFile(path)
+	if os.IsNotExist(err) {
+		file, err = &File{path: path, lines: []string{"# Shared combo configuration for this repository"}}, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	for _, key := range keys {
+		file.Set(key, values[key])
+	}
+	return path, file.write(0o644)
+}
+
+// openUserFile opens ~/.combo/config for editing, creating it if needed.
+func openUserFile() (*File, error) {
+	if err := EnsureUserFile(); err != nil {
+		return nil, err
+	}
+
+	path, err := UserPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return OpenFile(path)
+}
+
+// File is a key=value configuration file. Edits keep the comments, blank
+// lines and key order of the original file.
+type File struct {
+	path  string
+	lines []string
+}
+
+// OpenFile reads a key=value configuration file.
+func OpenFile(path string) (*File, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{path: path}
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		f.lines = append(f.lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	return f, nil
+}
+
+// Values parses the key=value pairs of the file.
+func (f *File) Values() (map[string]string, error) {
+	values := make(map[string]string)
+	for _, line := range f.lines {
+		key, value, ok, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration line in %s: %w", f.path, err)
+		}
+		if ok {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+// Set updates the key in place, or appends it if the file does not have it yet.
+func (f *File) Set(key, value string) {
+	line := key + "=" + value
+	for i, l := range f.lines {
+		if k, _, ok, _ := parseLine(l); ok && k == key {
+			f.lines[i] = line
+			return
+		}
+	}
+	f.lines = append(f.lines, line)
+}
+
+// Unset removes every line setting the key, reporting whether any was found.
+func (f *File) Unset(key string) bool {
+	kept := f.lines[:0]
+	removed := false
+	for _, l := range f.lines {
+		if k, _, ok, _ := parseLine(l); ok && k == key {
+			removed = true
+			continue
+		}
+		kept = append(kept, l)
+	}
+	f.lines = kept
+	return removed
+}
+
+// Save writes the file back to disk. Only files inside ~/.combo can be saved.
+func (f *File) Save() error {
+	dir, err := UserDir()
+	if err != nil {
+		return err
+	}
+	if !withinDir(dir, f.path) {
+		return fmt.Errorf("file path is outside the trusted directory: %s", f.path)
+	}
+
+	if err := f.write(0o600); err != nil {
+		return err
+	}
+	return enforcePrivate(f.path)
+}
+
+// write stores the lines of the file with the given permissions.
+func (f *File) write(perm os.FileMode) error {
+	content := strings.Join(f.lines, "\n") + "\n"
+	if err := os.WriteFile(filepath.Clean(f.path), []byte(content), perm); err != nil {
+		return fmt.Errorf("failed to write to config file: %w", err)
+	}
+	return nil
+}
+
+// ReadFile loads key=value pairs from a configuration file.
+func ReadFile(path string) (map[string]string, error) {
+	f, err := OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return f.Values()
+}
+
+// parseLine splits a key=value line. Empty lines and comments are skipped (ok is false).
+func parseLine(line string) (key, value string, ok bool, err error) {
+	line = strings.TrimSpace(line)
+	// Skip empty lines or comments
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false, nil
+	}
+
+	parts := strings.SplitN(line, "=", 2)
+	if len(parts) != 2 {
+		return "", "", false, fmt.Errorf("%s", line)
+	}
+
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), true, nil
+}
+
+// withinDir reports whether path lies inside dir.
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// readFileIfExists loads a key=value configuration file, returning nil if it does not exist.
+func readFileIfExists(path string) (map[string]string, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	values, err := ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// readYAMLFileIfExists loads a flat YAML mapping of keys to scalar values,
+// returning nil if the file does not exist.
+func readYAMLFileIfExists(path string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch value.(type) {
+		case map[string]any, []any:
+			return nil, fmt.Errorf("invalid value for %s in %s: expected a scalar", key, path)
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(value)
+		}
+	}
+	return values, nil
+}
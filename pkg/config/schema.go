@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Key describes a single configuration key: its default and how it is validated.
+type Key struct {
+	Name        string
+	Description string
+	Default     string
+	// Choices lists the allowed values of enumerated keys.
+	Choices []string
+	// Secret values are masked when displayed.
+	Secret bool
+	// UserOnly keys may not be set by a repository configuration, since anyone
+	// who can push to the repository controls that file.
+	UserOnly bool
+	// Validate checks a raw value; an empty value is replaced by the default first.
+	Validate func(value string) error
+}
+
+// Schema lists every known configuration key.
+type Schema []Key
+
+// Keys returns every key of the schema, sorted by name.
+func (s Schema) Keys() []Key {
+	keys := append([]Key(nil), s...)
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+	return keys
+}
+
+// Lookup returns the schema entry for a key.
+func (s Schema) Lookup(name string) (Key, bool) {
+	for _, key := range s {
+		if key.Name == name {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+// Validate checks that a value is acceptable for the named key.
+func (s Schema) Validate(name, value string) error {
+	key, ok := s.Lookup(name)
+	if !ok {
+		return s.unknownKeyError(name)
+	}
+	if value == "" {
+		value = key.Default
+	}
+	if key.Validate == nil {
+		return nil
+	}
+	if err := key.Validate(value); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", name, err)
+	}
+	return nil
+}
+
+// unknownKeyError reports an unknown key, suggesting the closest known one.
+func (s Schema) unknownKeyError(name string) error {
+	best, bestDistance := "", 5
+	for _, key := range s {
+		if d := distance(name, key.Name); d < bestDistance {
+			best, bestDistance = key.Name, d
+		}
+	}
+	if best != "" {
+		return fmt.Errorf("unknown configuration key %q (did you mean %q?)", name, best)
+	}
+	return fmt.Errorf("unknown configuration key %q", name)
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,569 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tolgaOzen/combo/pkg/classify"
+	"github.com/tolgaOzen/combo/pkg/git"
+	"github.com/tolgaOzen/combo/pkg/goast"
+	"github.com/tolgaOzen/combo/pkg/history"
+	"github.com/tolgaOzen/combo/pkg/issue"
+	"github.com/tolgaOzen/combo/pkg/prompt"
+	"github.com/tolgaOzen/combo/pkg/usage"
+)
+
+// Config holds the validated configuration used by the commands.
+type Config struct {
+	Provider             string
+	AzureEndpoint        string
+	AzureDeployment      string
+	AzureAPIVersion      string
+	AzureAuth            string
+	Model                string
+	CommitStyle          prompt.CommitStyle
+	CommitTypes          []string
+	CommitScopes         []string
+	PathRules            classify.Rules
+	AutoDetect           bool
+	OutputFormat         prompt.OutputFormat
+	GoSummary            goast.Mode
+	DetectBreaking       bool
+	DependencyMessages   bool
+	GitBackend           git.Backend
+	OpenAIAPIKey         string
+	APIKeyCommand        string
+	MaxRetries           int
+	RetryBaseDelay       time.Duration
+	CacheTTL             time.Duration
+	CacheMaxSizeMB       int
+	ModelPrices          usage.Prices
+	DailyBudgetUSD       float64
+	PromptLocale         prompt.Locale
+	PromptMaxLength      int
+	HistoryExamples      int
+	HistorySelection     history.Selection
+	IssuePattern         string
+	IssueReferenceFormat issue.Format
+	BranchTemplate       string
+}
+
+// setting is a configuration key together with where its value is stored in Config.
+type setting struct {
+	Key
+	// apply validates a raw value and stores it in the Config.
+	apply func(cfg *Config, value string) error
+}
+
+// settings lists every known configuration key and where its value is stored in Config.
+var settings = []setting{
+	{
+		Key: Key{
+			Name:        "provider",
+			Description: "LLM provider used to generate messages",
+			Default:     "openai",
+			Choices:     []string{"openai", "azure"},
+		},
+		apply: func(cfg *Config, value string) error {
+			if err := oneOf(value, "openai", "azure"); err != nil {
+				return err
+			}
+			cfg.Provider = value
+			return nil
+		},
+	},
+	{
+		Key: Key{
+			Name:        "azure_endpoint",
+			Description: "Azure OpenAI resource endpoint, e.g. https://my-resource.openai.azure.com",
+			UserOnly:    true,
+		},
+		apply: func(cfg *Config, value string) error {
+			if value != "" {
+				u, err := url.Parse(value)
+				if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+					return fmt.Errorf("expected an http(s) URL, got %q", value)
+				}
+			}
+			cfg.AzureEndpoint = value
+			return nil
+		},
+	},
+	{
+		Key: Key{
+			Name:        "azure_deployment",
+			Description: "Azure OpenAI deployment; empty derives it from the model name",
+		},
+		apply: func(cfg *Config, value string) error {
+			cfg.AzureDeployment = value
+			return nil
+		},
+	},
+	{
+		Key: Key{
+			Name:        "azure_api_version",
+			Description: "Azure OpenAI REST API version",
+			Default:     "2024-10-21",
+		},
+		apply: func(cfg *Config, value string) error {
+			cfg.AzureAPIVersion = value
+			return nil
+		},
+	},
+	{
+		Key: Key{
+			Name:        "azure_auth",
+			Description: "how the key is sent to Azure: api-key, or bearer for a Microsoft Entra ID token",
+			Default:     "api-key",
+			Choices:     []string{"api-key", "bearer"},
+		},
+		apply: func(cfg *Config, value string) error {
+			if err := oneOf(value, "api-key", "bearer"); err != nil {
+				return err
+			}
+			cfg.AzureAuth = value
+			return nil
+		},
+	},
+	{
+		Key: Key{
+			Name:        "model",
+			Description: "model used to generate messages",
+			Default:     "gpt-3.5-turbo",
+		},
+		apply: func(cfg *Config, value string) error {
+			cfg.Model = value
+			return nil
+		},
+	},
+	{
+		Key: Key{
+			Name:        "commit_style",
+			Description: "commit message format (conventional, gitmoji, plain)",
+			Default:     "conventional",
+			Choices:     []string{"conventional", "gitmoji", "plain"},
+		},
+		apply: func(cfg *Config, value string) error {
+			if err := oneOf(value, "conventional", "gitmoji", "plain"); err != nil {
+				return err
+			}
+			cfg.CommitStyle = prompt.CommitStyle(value)
+			if value == "plain" {
+				cfg.CommitStyle = prompt.Empty
+			}
+			return nil
+		},
+	},
+	{
+		Key: Key{
+			Name:        "commit_types",
+			Description: "comma-separated commit types, or gitmojis, the model may choose from; empty allows all",
+		},
+		apply: func(cfg *Config, value string) error {
+			cfg.CommitTypes = nil
+			for _, t := range splitList(value) {
+				_, gitmoji := prompt.LookupGitmoji(t)
+				if !gitmoji && !isCommitType(t) {
+					return fmt.Errorf("unknown commit type %q", t)
+				}
+				cfg.CommitTypes = append(cfg.CommitTypes, t)
+			}
+			return nil
+		},
+	},
+	{
+		Key: Key{
+			Name:        "commit_scopes",
+			Description: "comma-separated scopes the model should prefer, e.g. api,cli,docs",
+		},
+		apply: func(cfg *Config, value string) error {
+			cfg.CommitScopes = splitList(value)
+			return nil
+		},
+	},
+	{
+		Key: Key{
+			Name:        "path_rules",
+			Description: "commit types implied by file paths, e.g. docs=*.md|docs/**,test=e2e/**; replaces the defaults per type",
+		},
+		apply: func(cfg *Config, value string) (err error) {
+			cfg.PathRules, err = classify.ParseRules(value, isCommitType)
+			return err
+		},
+	},
+	{
+		Key: Key{
+			Name:        "auto_detect",
+			Description: "detect the commit conventions from history when the repository has no configuration",
+			Default:     "false",
+		},
+		apply: func(cfg *Config, value string) (err error) {
+			cfg.AutoDetect, err = parseBool(value)
+			return err
+		},
+	},
+	{
+		Key: Key{
+			Name:        "output_format",
+			Description: "how the model returns commit messages (json, json_schema, text)",
+			Default:     prompt.JSON.String(),
+			Choices:     []string{prompt.JSON.String(), prompt.JSONSchema.String(), prompt.Text.String()},
+		},
+		apply: func(cfg *Config, value string) error {
+			if err := oneOf(value, prompt.JSON.String(), prompt.JSONSchema.String(), prompt.Text.String()); err != nil {
+				return err
+			}
+			cfg.OutputFormat = prompt.OutputFormat(value)
+			return nil
+		},
+	},
+	{
+		Key: Key{
+			Name:        "go_summary",
+			Description: "summary of changed Go declarations sent with the diff (append, replace, off)",
+			Default:     goast.Append.String(),
+			Choices:     []string{goast.Append.String(), goast.Replace.String(), goast.Off.String()},
+		},
+		apply: func(cfg *Config, value string) (err error) {
+			cfg.GoSummary, err = goast.ParseMode(value)
+			return err
+		},
+	},
+	{
+		Key: Key{
+			Name:        "dependency_messages",
+			Description: "describe changes made only of dependency manifests without calling the model",
+			Default:     "true",
+		},
+		apply: func(cfg *Config, value string) (err error) {
+			cfg.DependencyMessages, err = parseBool(value)
+			return err
+		},
+	},
+	{
+		Key: Key{
+			Name:        "detect_breaking",
+			Description: "mark commits that break the exported Go API of a package as breaking changes",
+			Default:     "true",
+		},
+		apply: func(cfg *Config, value string) (err error) {
+			cfg.DetectBreaking, err = parseBool(value)
+			return err
+		},
+	},
+	{
+		Key: Key{
+			Name:        "git_backend",
+			Description: "how the repository is accessed (exec runs the git binary, go-git needs none but runs no hooks)",
+			Default:     git.ExecBackend.String(),
+			Choices:     []string{git.ExecBackend.String(), git.GoGitBackend.String()},
+			UserOnly:    true,
+		},
+		apply: func(cfg *Config, value string) (err error) {
+			cfg.GitBackend, err = git.ParseBackend(value)
+			return err
+		},
+	},
+	{
+		Key: Key{
+			Name:        "openai_api_key",
+			Description: "OpenAI API key",
+			Secret:      true,
+			UserOnly:    true,
+		},
+		apply: func(cfg *Config, value string) error {
+			cfg.OpenAIAPIKey = value
+			return nil
+		},
+	},
+	{
+		Key: Key{
+			Name:        "api_key_command",
+			Description: "shell command that prints the API key, e.g. `pass show openai`",
+			UserOnly:    true,
+		},
+		apply: func(cfg *Config, value string) error {
+			cfg.APIKeyCommand = value
+			return nil
+		},
+	},
+	{
+		Key: Key{
+			Name:        "max_retries",
+			Description: "retries after a rate limit, server error or network failure",
+			Default:     "3",
+		},
+		apply: func(cfg *Config, value string) (err error) {
+			cfg.MaxRetries, err = parseInt(value, 0, 10)
+			return err
+		},
+	},
+	{
+		Key: Key{
+			Name:        "retry_base_delay",
+			Description: "delay before the first retry, doubled for each further retry",
+			Default:     "1s",
+		},
+		apply: func(cfg *Config, value string) (err error) {
+			cfg.RetryBaseDelay, err = parseDuration(value, 10*time.Millisecond, time.Minute)
+			return err
+		},
+	},
+	{
+		Key: Key{
+			Name:        "cache_ttl",
+			Description: "how long generated responses are reused; 0 disables the cache",
+			Default:     "24h",
+		},
+		apply: func(cfg *Config, value string) (err error) {
+			cfg.CacheTTL, err = parseDuration(value, 0, 30*24*time.Hour)
+			return err
+		},
+	},
+	{
+		Key: Key{
+			Name:        "cache_max_size_mb",
+			Description: "size limit of the response cache in megabytes",
+			Default:     "20",
+		},
+		apply: func(cfg *Config, value string) (err error) {
+			cfg.CacheMaxSizeMB, err = parseInt(value, 1, 1024)
+			return err
+		},
+	},
+	{
+		Key: Key{
+			Name:        "model_prices",
+			Description: "price overrides in USD per million tokens, e.g. gpt-4o=2.5/10,my-model=1/2",
+			UserOnly:    true,
+		},
+		apply: func(cfg *Config, value string) (err error) {
+			cfg.ModelPrices, err = usage.ParsePrices(value)
+			return err
+		},
+	},
+	{
+		Key: Key{
+			Name:        "daily_budget_usd",
+			Description: "estimated spend per day after which calls are refused; 0 means no limit",
+			Default:     "0",
+			UserOnly:    true,
+		},
+		apply: func(cfg *Config, value string) (err error) {
+			cfg.DailyBudgetUSD, err = parseFloat(value, 0)
+			return err
+		},
+	},
+	{
+		Key: Key{
+			Name:        "prompt_locale",
+			Description: "language of generated messages",
+			Default:     prompt.EnUS.String(),
+		},
+		apply: func(cfg *Config, value string) error {
+			for _, locale := range prompt.SupportedLocales() {
+				if value == locale.String() {
+					cfg.PromptLocale = locale
+					return nil
+				}
+			}
+			return fmt.Errorf("unsupported locale %q", value)
+		},
+	},
+	{
+		Key: Key{
+			Name:        "prompt_max_length",
+			Description: "maximum length of a commit subject",
+			Default:     "72",
+		},
+		apply: func(cfg *Config, value string) (err error) {
+			cfg.PromptMaxLength, err = parseInt(value, 10, 200)
+			return err
+		},
+	},
+	{
+		Key: Key{
+			Name:        "history_examples",
+			Description: "past commit messages shown to the model as style examples; 0 disables them",
+			Default:     "0",
+		},
+		apply: func(cfg *Config, value string) (err error) {
+			cfg.HistoryExamples, err = parseInt(value, 0, 20)
+			return err
+		},
+	},
+	{
+		Key: Key{
+			Name:        "history_selection",
+			Description: "which past commits are used as examples (recent, similar)",
+			Default:     history.Similar.String(),
+			Choices:     []string{history.Recent.String(), history.Similar.String()},
+		},
+		apply: func(cfg *Config, value string) (err error) {
+			cfg.HistorySelection, err = history.ParseSelection(value)
+			return err
+		},
+	},
+	{
+		Key: Key{
+			Name:        "issue_pattern",
+			Description: "regex that finds an issue key in the branch name",
+			Default:     issue.DefaultPattern,
+		},
+		apply: func(cfg *Config, value string) error {
+			if _, err := regexp.Compile(value); err != nil {
+				return fmt.Errorf("invalid regular expression: %w", err)
+			}
+			cfg.IssuePattern = value
+			return nil
+		},
+	},
+	{
+		Key: Key{
+			Name:        "issue_reference_format",
+			Description: "how issue keys are attached to commit messages (refs, closes, prefix, none)",
+			Default:     issue.Refs.String(),
+			Choices:     []string{issue.Refs.String(), issue.Closes.String(), issue.Prefix.String(), issue.None.String()},
+		},
+		apply: func(cfg *Config, value string) (err error) {
+			cfg.IssueReferenceFormat, err = issue.ParseFormat(value)
+			return err
+		},
+	},
+	{
+		Key: Key{
+			Name:        "branch_template",
+			Description: "template for generated branch names",
+			Default:     issue.DefaultBranchTemplate,
+		},
+		apply: func(cfg *Config, value string) error {
+			if _, err := issue.RenderBranchName(value, issue.BranchData{Name: "name", Issue: "KEY-1"}); err != nil {
+				return err
+			}
+			cfg.BranchTemplate = value
+			return nil
+		},
+	},
+}
+
+// Settings describes every configuration key of combo, validating a value by
+// applying it to an empty Config.
+var Settings = newSchema()
+
+// newSchema builds the schema from the settings.
+func newSchema() Schema {
+	keys := make(Schema, 0, len(settings))
+	for _, s := range settings {
+		key, apply := s.Key, s.apply
+		key.Validate = func(value string) error {
+			return apply(&Config{}, value)
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// FromValues validates raw values and converts them into a Config.
+func FromValues(values map[string]Value) (*Config, error) {
+	cfg := &Config{}
+	for _, s := range settings {
+		v, ok := values[s.Name]
+		if !ok {
+			continue
+		}
+		// An empty value falls back to the default
+		if v.Value == "" {
+			v.Value = s.Default
+		}
+		if err := s.apply(cfg, v.Value); err != nil {
+			return nil, fmt.Errorf("invalid value for %s (from %s): %w", s.Name, v.Origin, err)
+		}
+	}
+	return cfg, nil
+}
+
+// APIKey resolves the API key from openai_api_key, api_key_command or the
+// encrypted credential file.
+func (cfg *Config) APIKey(passphrase PassphraseFunc) (string, error) {
+	return APIKey(cfg.OpenAIAPIKey, cfg.APIKeyCommand, passphrase)
+}
+
+// oneOf checks that value is one of the allowed choices.
+func oneOf(value string, choices ...string) error {
+	for _, choice := range choices {
+		if value == choice {
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of %s, got %q", strings.Join(choices, ", "), value)
+}
+
+// parseInt parses an integer within [minimum, maximum].
+func parseInt(value string, minimum, maximum int) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("expected an integer, got %q", value)
+	}
+	if n < minimum || n > maximum {
+		return 0, fmt.Errorf("must be between %d and %d", minimum, maximum)
+	}
+	return n, nil
+}
+
+// parseBool parses true or false.
+func parseBool(value string) (bool, error) {
+	b, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return false, fmt.Errorf("expected true or false, got %q", value)
+	}
+	return b, nil
+}
+
+// splitList splits a comma-separated value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// isCommitType reports whether value is a conventional commit type.
+func isCommitType(value string) bool {
+	for _, t := range prompt.CommitTypes() {
+		if value == t.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFloat parses a number that is at least minimum.
+func parseFloat(value string, minimum float64) (float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, fmt.Errorf("expected a number, got %q", value)
+	}
+	if f < minimum {
+		return 0, fmt.Errorf("must be at least %g", minimum)
+	}
+	return f, nil
+}
+
+// parseDuration parses a duration such as "500ms" or "2s" within [minimum, maximum].
+func parseDuration(value string, minimum, maximum time.Duration) (time.Duration, error) {
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("expected a duration such as 500ms or 2s, got %q", value)
+	}
+	if d < minimum || d > maximum {
+		return 0, fmt.Errorf("must be between %s and %s", minimum, maximum)
+	}
+	return d, nil
+}
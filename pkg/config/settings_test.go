@@ -0,0 +1,45 @@
+package config
+
+import "testing"
+
+func TestSchemaDefaults(t *testing.T) {
+	values := make(map[string]Value)
+	for _, key := range Settings {
+		if err := Settings.Validate(key.Name, ""); err != nil {
+			t.Errorf("default of %s: %v", key.Name, err)
+		}
+		values[key.Name] = Value{Value: key.Default, Origin: "default"}
+	}
+
+	cfg, err := FromValues(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Model != "gpt-3.5-turbo" || cfg.PromptMaxLength != 72 || cfg.MaxRetries != 3 {
+		t.Errorf("unexpected defaults %+v", cfg)
+	}
+}
+
+func TestUserOnlySettings(t *testing.T) {
+	want := map[string]bool{
+		"azure_endpoint":   true,
+		"git_backend":      true,
+		"openai_api_key":   true,
+		"api_key_command":  true,
+		"model_prices":     true,
+		"daily_budget_usd": true,
+	}
+	for _, key := range Settings {
+		if key.UserOnly != want[key.Name] {
+			t.Errorf("%s: UserOnly = %v, want %v", key.Name, key.UserOnly, want[key.Name])
+		}
+	}
+}
+
+func TestFromValuesReportsOrigin(t *testing.T) {
+	_, err := FromValues(map[string]Value{"max_retries": {Value: "x", Origin: "env:COMBO_MAX_RETRIES"}})
+	want := `invalid value for max_retries (from env:COMBO_MAX_RETRIES): expected an integer, got "x"`
+	if err == nil || err.Error() != want {
+		t.Errorf("FromValues() = %v, want %q", err, want)
+	}
+}
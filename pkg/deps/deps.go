@@ -0,0 +1,231 @@
+package deps
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tolgaOzen/combo/pkg/git"
+	"github.com/tolgaOzen/combo/pkg/prompt"
+)
+
+// Bump is a dependency whose version changed. From is empty for an added
+// dependency and To is empty for a removed one.
+type Bump struct {
+	Name string
+	From string
+	To   string
+}
+
+// String describes the bump as in "bump X from A to B".
+func (b Bump) String() string {
+	switch {
+	case b.From == "":
+		return fmt.Sprintf("add %s %s", b.Name, b.To)
+	case b.To == "":
+		return fmt.Sprintf("remove %s %s", b.Name, b.From)
+	}
+	return fmt.Sprintf("bump %s from %s to %s", b.Name, b.From, b.To)
+}
+
+// manifest reads the dependencies of one kind of dependency file. ok is false
+// if the two versions differ in anything but dependency versions.
+type manifest func(old, new []byte) (bumps []Bump, ok bool, err error)
+
+// manifests maps the names of dependency files to their parsers. Files mapped
+// to nil, such as go.sum, only follow the manifests and are allowed alongside them.
+var manifests = map[string]manifest{
+	"go.mod":              goMod,
+	"go.sum":              nil,
+	"package.json":        packageJSON,
+	"package-lock.json":   packageLock,
+	"npm-shrinkwrap.json": packageLock,
+	"yarn.lock":           nil,
+	"pnpm-lock.yaml":      nil,
+}
+
+// lookup returns the parser of a dependency file and whether the file is one.
+func lookup(file string) (manifest, bool) {
+	name := path.Base(file)
+	if strings.HasPrefix(name, "requirements") && path.Ext(name) == ".txt" {
+		return requirements, true
+	}
+	m, ok := manifests[name]
+	return m, ok
+}
+
+// Detect reports the dependency bumps of a staged change made only of
+// dependency files: go.mod and go.sum, package.json and its lockfiles, and
+// requirements*.txt. It returns nil if any other file is staged, a manifest
+// changed in some other way or cannot be parsed, or no version changed.
+func Detect(staged *git.DiffResult) ([]Bump, error) {
+	files := staged.Paths()
+	if len(files) == 0 {
+		return nil, nil
+	}
+	for _, file := range files {
+		if _, ok := lookup(file); !ok {
+			return nil, nil
+		}
+	}
+
+	// Lockfiles come last, so their exact versions replace the ranges of package.json
+	bumps := make(map[string]Bump)
+	for _, file := range sortedFiles(files) {
+		parse, _ := lookup(file)
+		if parse == nil {
+			continue
+		}
+
+		old, _, err := git.ShowFile(staged.Base, file)
+		if err != nil {
+			return nil, err
+		}
+		new, _, err := git.ShowFile("", file)
+		if err != nil {
+			return nil, err
+		}
+		// A manifest that cannot be parsed is left to the model
+		found, ok, err := parse(old, new)
+		if err != nil || !ok {
+			return nil, nil
+		}
+		for _, bump := range found {
+			bumps[bump.Name] = bump
+		}
+	}
+
+	result := make([]Bump, 0, len(bumps))
+	for _, bump := range bumps {
+		result = append(result, bump)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+// sortedFiles orders the manifests before their lockfiles.
+func sortedFiles(files []string) []string {
+	sorted := append([]string(nil), files...)
+	rank := func(file string) int {
+		if strings.HasSuffix(file, ".json") && path.Base(file) != "package.json" {
+			return 1
+		}
+		return 0
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return rank(sorted[i]) < rank(sorted[j]) })
+	return sorted
+}
+
+// Message builds the commit message for a set of bumps in the given style: a
+// single bump is described in the subject, several are grouped and listed in
+// the body. The type is build, or chore if only that is among the allowed
+// types; an empty list allows every type. ok is false if neither is allowed,
+// leaving the message to the model.
+func Message(bumps []Bump, style prompt.CommitStyle, types []string) (msg prompt.CommitMessage, ok bool) {
+	msg.Scope = "deps"
+	if len(bumps) == 1 {
+		msg.Subject = bumps[0].String()
+	} else {
+		msg.Subject = fmt.Sprintf("bump %d dependencies", len(bumps))
+		lines := make([]string, len(bumps))
+		for i, bump := range bumps {
+			lines[i] = "- " + bump.String()
+		}
+		msg.Body = strings.Join(lines, "\n")
+	}
+
+	switch style {
+	case prompt.Gitmoji:
+		msg.Scope = ""
+		msg.Type, ok = allowedType(types, canonicalGitmoji, gitmoji(bumps), "⬆️")
+	case prompt.Empty:
+		msg.Scope = ""
+		ok = true
+	default:
+		msg.Type, ok = allowedType(types, strings.ToLower, prompt.Build.String(), prompt.Chore.String())
+	}
+	return msg, ok
+}
+
+// allowedType returns the first candidate among the allowed types, comparing
+// both in the form returned by canonical.
+func allowedType(types []string, canonical func(string) string, candidates ...string) (string, bool) {
+	if len(types) == 0 {
+		return candidates[0], true
+	}
+	for _, candidate := range candidates {
+		for _, t := range types {
+			if canonical(t) == canonical(candidate) {
+				return candidate, true
+			}
+		}
+	}
+	return "", false
+}
+
+// canonicalGitmoji spells a gitmoji as its emoji, whether given as one or as its code.
+func canonicalGitmoji(value string) string {
+	if g, ok := prompt.LookupGitmoji(value); ok {
+		return g.Emoji
+	}
+	return value
+}
+
+// gitmoji picks the gitmoji describing every bump, or ⬆️ for a mix.
+func gitmoji(bumps []Bump) string {
+	emoji := ""
+	for _, bump := range bumps {
+		e := "⬆️"
+		switch {
+		case bump.From == "":
+			e = "➕"
+		case bump.To == "":
+			e = "➖"
+		case compareVersions(bump.From, bump.To) > 0:
+			e = "⬇️"
+		}
+		if emoji != "" && emoji != e {
+			return "⬆️"
+		}
+		emoji = e
+	}
+	return emoji
+}
+
+// compareVersions compares two version strings by their numeric parts, so that
+// v1.10.0 follows v1.9.2 and ^2.0.0 follows ~1.4.
+func compareVersions(a, b string) int {
+	pa, pb := numbers(a), numbers(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// numbers returns the numeric parts of a version string.
+func numbers(version string) []int {
+	fields := strings.FieldsFunc(version, func(r rune) bool { return r < '0' || r > '9' })
+	result := make([]int, 0, len(fields))
+	for _, field := range fields {
+		n, _ := strconv.Atoi(field)
+		result = append(result, n)
+	}
+	return result
+}
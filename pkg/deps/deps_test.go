@@ -0,0 +1,40 @@
+package deps
+
+import (
+	"testing"
+
+	"github.com/tolgaOzen/combo/pkg/prompt"
+)
+
+func TestMessage(t *testing.T) {
+	bump := []Bump{{Name: "golang.org/x/term", From: "v0.1.0", To: "v0.2.0"}}
+
+	tests := []struct {
+		name   string
+		style  prompt.CommitStyle
+		types  []string
+		want   string
+		wantOK bool
+	}{
+		{name: "any type", style: prompt.Conventional, want: "build(deps): bump golang.org/x/term from v0.1.0 to v0.2.0", wantOK: true},
+		{name: "build allowed", style: prompt.Conventional, types: []string{"feat", "build", "chore"}, want: "build(deps): bump golang.org/x/term from v0.1.0 to v0.2.0", wantOK: true},
+		{name: "chore fallback", style: prompt.Conventional, types: []string{"feat", "fix", "chore"}, want: "chore(deps): bump golang.org/x/term from v0.1.0 to v0.2.0", wantOK: true},
+		{name: "neither allowed", style: prompt.Conventional, types: []string{"feat", "fix"}},
+		{name: "gitmoji code", style: prompt.Gitmoji, types: []string{":arrow_up:"}, want: "⬆️ bump golang.org/x/term from v0.1.0 to v0.2.0", wantOK: true},
+		{name: "gitmoji not allowed", style: prompt.Gitmoji, types: []string{"✨"}},
+		{name: "plain", style: prompt.Empty, types: []string{"feat"}, want: "bump golang.org/x/term from v0.1.0 to v0.2.0", wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, ok := Message(bump, tt.style, tt.types)
+			if ok != tt.wantOK {
+				t.Fatalf("Message() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok {
+				if got := msg.Render(tt.style); got != tt.want {
+					t.Errorf("Message() = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package deps
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// compare lists the bumps between two name-to-version maps.
+func compare(old, new map[string]string) []Bump {
+	var bumps []Bump
+	for name, from := range old {
+		if to := new[name]; to != from {
+			bumps = append(bumps, Bump{Name: name, From: from, To: to})
+		}
+	}
+	for name, to := range new {
+		if _, ok := old[name]; !ok {
+			bumps = append(bumps, Bump{Name: name, To: to})
+		}
+	}
+	sort.Slice(bumps, func(i, j int) bool { return bumps[i].Name < bumps[j].Name })
+	return bumps
+}
+
+// goMod reads the requirements of a go.mod file. Any other change, such as a
+// new go directive or replace, is not a plain dependency bump.
+func goMod(old, new []byte) ([]Bump, bool, error) {
+	oldRequires, oldRest := parseGoMod(old)
+	newRequires, newRest := parseGoMod(new)
+	if oldRest != newRest {
+		return nil, false, nil
+	}
+	return compare(oldRequires, newRequires), true, nil
+}
+
+// parseGoMod splits a go.mod file into its required module versions and the
+// remaining directives, with comments and blank lines removed.
+func parseGoMod(data []byte) (map[string]string, string) {
+	requires := make(map[string]string)
+	var rest []string
+	block := false
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case block && fields[0] == ")":
+			block = false
+		case block && len(fields) == 2:
+			requires[fields[0]] = fields[1]
+		case fields[0] == "require" && len(fields) == 2 && fields[1] == "(":
+			block = true
+		case fields[0] == "require" && len(fields) == 3:
+			requires[fields[1]] = fields[2]
+		default:
+			rest = append(rest, strings.Join(fields, " "))
+		}
+	}
+	return requires, strings.Join(rest, "\n")
+}
+
+// dependencySections are the package.json fields that hold dependencies.
+var dependencySections = []string{"dependencies", "devDependencies", "peerDependencies", "optionalDependencies"}
+
+// packageJSON reads the dependencies of a package.json file. A change to any
+// other field, such as the package's own version, is not a plain bump.
+func packageJSON(old, new []byte) ([]Bump, bool, error) {
+	oldDeps, oldRest, err := parsePackageJSON(old)
+	if err != nil {
+		return nil, false, err
+	}
+	newDeps, newRest, err := parsePackageJSON(new)
+	if err != nil {
+		return nil, false, err
+	}
+	if oldRest != newRest {
+		return nil, false, nil
+	}
+	return compare(oldDeps, newDeps), true, nil
+}
+
+// parsePackageJSON splits a package.json file into its dependency versions and
+// the remaining fields, re-encoded in a canonical form.
+func parsePackageJSON(data []byte) (map[string]string, string, error) {
+	deps := make(map[string]string)
+	if len(data) == 0 {
+		return deps, "", nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, "", fmt.Errorf("failed to parse package.json: %w", err)
+	}
+	for _, section := range dependencySections {
+		var versions map[string]string
+		if raw, ok := fields[section]; ok {
+			if err := json.Unmarshal(raw, &versions); err != nil {
+				return nil, "", fmt.Errorf("failed to parse package.json %s: %w", section, err)
+			}
+		}
+		for name, version := range versions {
+			deps[name] = version
+		}
+		delete(fields, section)
+	}
+
+	rest, err := canonicalJSON(fields)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse package.json: %w", err)
+	}
+	return deps, rest, nil
+}
+
+// canonicalJSON encodes JSON fields in a form that ignores formatting and
+// field order.
+func canonicalJSON(fields map[string]json.RawMessage) (string, error) {
+	// Marshalling a map sorts its keys, and compacting drops the formatting
+	compact := make(map[string]json.RawMessage, len(fields))
+	for name, raw := range fields {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, raw); err != nil {
+			return "", err
+		}
+		compact[name] = buf.Bytes()
+	}
+	canonical, err := json.Marshal(compact)
+	if err != nil {
+		return "", err
+	}
+	return string(canonical), nil
+}
+
+// packageLock reads the installed versions of the direct dependencies from a
+// package-lock.json or npm-shrinkwrap.json file, lockfile version 2 or later.
+// A change to the lockfile's own fields or to those of the root package, such
+// as its version, is not a plain bump; transitive packages may change freely.
+func packageLock(old, new []byte) ([]Bump, bool, error) {
+	oldDeps, oldRest, err := parsePackageLock(old)
+	if err != nil {
+		return nil, false, err
+	}
+	newDeps, newRest, err := parsePackageLock(new)
+	if err != nil {
+		return nil, false, err
+	}
+	if oldRest != newRest {
+		return nil, false, nil
+	}
+	return compare(oldDeps, newDeps), true, nil
+}
+
+// parsePackageLock returns the versions of the packages the root package
+// depends on directly, leaving transitive updates out, and the remaining
+// fields of the lockfile and its root package in a canonical form.
+func parsePackageLock(data []byte) (map[string]string, string, error) {
+	deps := make(map[string]string)
+	if len(data) == 0 {
+		return deps, "", nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, "", fmt.Errorf("failed to parse package lockfile: %w", err)
+	}
+	var packages map[string]map[string]json.RawMessage
+	if raw, ok := fields["packages"]; ok {
+		if err := json.Unmarshal(raw, &packages); err != nil {
+			return nil, "", fmt.Errorf("failed to parse package lockfile packages: %w", err)
+		}
+	}
+
+	root := packages[""]
+	for _, section := range dependencySections {
+		var versions map[string]string
+		if raw, ok := root[section]; ok {
+			if err := json.Unmarshal(raw, &versions); err != nil {
+				return nil, "", fmt.Errorf("failed to parse package lockfile %s: %w", section, err)
+			}
+		}
+		for name := range versions {
+			raw, ok := packages["node_modules/"+name]["version"]
+			if !ok {
+				continue
+			}
+			var version string
+			if err := json.Unmarshal(raw, &version); err != nil {
+				return nil, "", fmt.Errorf("failed to parse package lockfile version of %s: %w", name, err)
+			}
+			deps[name] = version
+		}
+		delete(root, section)
+	}
+
+	// Only the root package is kept of the tree. Version 2 lockfiles repeat
+	// the tree in dependencies for older npm releases.
+	delete(fields, "packages")
+	delete(fields, "dependencies")
+	if root != nil {
+		canonicalRoot, err := canonicalJSON(root)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse package lockfile: %w", err)
+		}
+		fields["packages"] = json.RawMessage(canonicalRoot)
+	}
+	rest, err := canonicalJSON(fields)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse package lockfile: %w", err)
+	}
+	return deps, rest, nil
+}
+
+// requirementPattern matches a pinned or constrained requirement such as
+// "requests==2.31.0" or "Django>=4.2 ; python_version >= '3.8'".
+var requirementPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)(\[[^\]]*\])?\s*(===|==|~=|>=|<=|!=|>|<)\s*([^\s;,#]+)`)
+
+// requirements reads the packages of a pip requirements file. Lines that are
+// not requirements, such as options or includes, must not change.
+func requirements(old, new []byte) ([]Bump, bool, error) {
+	oldRequirements, oldRest := parseRequirements(old)
+	newRequirements, newRest := parseRequirements(new)
+	if oldRest != newRest {
+		return nil, false, nil
+	}
+	return compare(oldRequirements, newRequirements), true, nil
+}
+
+// parseRequirements splits a requirements file into package versions, keyed
+// by normalised package name, and the remaining lines. Exact pins are reduced
+// to the version, other constraints keep their operator.
+func parseRequirements(data []byte) (map[string]string, string) {
+	requirements := make(map[string]string)
+	var rest []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		match := requirementPattern.FindStringSubmatch(line)
+		if match == nil {
+			rest = append(rest, line)
+			continue
+		}
+		name := strings.ToLower(strings.NewReplacer("_", "-", ".", "-").Replace(match[1]))
+		version := match[4]
+		if match[3] != "==" && match[3] != "===" {
+			version = match[3] + version
+		}
+		requirements[name] = version
+	}
+	return requirements, strings.Join(rest, "\n")
+}
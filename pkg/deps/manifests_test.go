@@ -0,0 +1,114 @@
+package deps
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fixture reads the old and new versions of a dependency file from testdata.
+func fixture(t *testing.T, name string) (old, new []byte) {
+	t.Helper()
+	old, err := os.ReadFile(filepath.Join("testdata", name, "old"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	new, err = os.ReadFile(filepath.Join("testdata", name, "new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return old, new
+}
+
+func TestManifests(t *testing.T) {
+	tests := []struct {
+		fixture string
+		parse   manifest
+		want    []Bump
+		wantOK  bool
+	}{
+		{
+			fixture: "gomod-indirect",
+			parse:   goMod,
+			want: []Bump{
+				{Name: "github.com/spf13/cobra", From: "v1.8.0", To: "v1.8.1"},
+				{Name: "github.com/spf13/pflag", From: "v1.0.5", To: "v1.0.6"},
+				{Name: "golang.org/x/sys", To: "v0.20.0"},
+			},
+			wantOK: true,
+		},
+		{fixture: "gomod-replace", parse: goMod},
+		{
+			fixture: "packagejson-deps",
+			parse:   packageJSON,
+			want: []Bump{
+				{Name: "left-pad", From: "1.3.0"},
+				{Name: "react", From: "^18.2.0", To: "^18.3.1"},
+				{Name: "typescript", To: "^5.4.0"},
+			},
+			wantOK: true,
+		},
+		{fixture: "packagejson-version", parse: packageJSON},
+		{
+			fixture: "lock-direct",
+			parse:   packageLock,
+			want:    []Bump{{Name: "react", From: "18.2.0", To: "18.3.1"}},
+			wantOK:  true,
+		},
+		{fixture: "lock-transitive", parse: packageLock, wantOK: true},
+		{fixture: "lock-version", parse: packageLock},
+		{
+			fixture: "requirements-names",
+			parse:   requirements,
+			want: []Bump{
+				{Name: "django-rest-framework", From: "3.14.0", To: "3.15.1"},
+				{Name: "pyyaml", From: ">=6.0", To: ">=6.0.1"},
+			},
+			wantOK: true,
+		},
+		{fixture: "requirements-options", parse: requirements},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			old, new := fixture(t, tt.fixture)
+			bumps, ok, err := tt.parse(old, new)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !reflect.DeepEqual(bumps, tt.want) {
+				t.Errorf("bumps = %v, want %v", bumps, tt.want)
+			}
+		})
+	}
+}
+
+func TestManifestsAddedFile(t *testing.T) {
+	_, new := fixture(t, "lock-direct")
+	bumps, ok, err := packageLock(nil, new)
+	if err != nil || ok {
+		t.Errorf("packageLock() of a new lockfile = %v, %v, %v, want a fallback", bumps, ok, err)
+	}
+
+	_, new = fixture(t, "requirements-names")
+	bumps, ok, err = requirements(nil, new)
+	want := []Bump{
+		{Name: "django-rest-framework", To: "3.15.1"},
+		{Name: "pyyaml", To: ">=6.0.1"},
+		{Name: "requests", To: "2.31.0"},
+	}
+	if err != nil || !ok || !reflect.DeepEqual(bumps, want) {
+		t.Errorf("requirements() of a new file = %v, %v, %v, want %v", bumps, ok, err, want)
+	}
+}
+
+func TestManifestsInvalidJSON(t *testing.T) {
+	for name, parse := range map[string]manifest{"package.json": packageJSON, "package-lock.json": packageLock} {
+		if _, _, err := parse([]byte("{}"), []byte("{")); err == nil {
+			t.Errorf("%s: invalid JSON was accepted", name)
+		}
+	}
+}
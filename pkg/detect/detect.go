@@ -0,0 +1,164 @@
+package detect
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tolgaOzen/combo/pkg/git"
+	"github.com/tolgaOzen/combo/pkg/prompt"
+)
+
+// MinCommits is the number of commits needed before conventions are reported.
+const MinCommits = 10
+
+// poolSize is how many of the latest commits are analysed.
+const poolSize = 300
+
+// Conventions describes how a repository writes its commit messages.
+type Conventions struct {
+	Commits   int                // Number of commits analysed.
+	Style     prompt.CommitStyle // Conventional, Gitmoji or Empty for plain messages.
+	Types     []string           // Commit types or gitmojis in use, most common first.
+	Scopes    []string           // Scopes used at least twice, most common first.
+	MaxLength int                // Subject length that nine in ten commits stay within.
+	Locale    prompt.Locale      // Dominant language of the messages.
+}
+
+// Detect analyses the latest commits of the current repository.
+func Detect() (Conventions, error) {
+	commits, err := git.RecentCommits(poolSize)
+	if err != nil {
+		return Conventions{}, err
+	}
+	return Analyse(commits), nil
+}
+
+// conventionalHeader matches "type(scope)!: subject".
+var conventionalHeader = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^()]*)\))?!?:\s*\S`)
+
+// maxScopes caps the number of scopes reported.
+const maxScopes = 15
+
+// Analyse works out the conventions of the given commits. Merges and reverts
+// are skipped, since their messages are usually generated by git.
+func Analyse(commits []git.Commit) Conventions {
+	var (
+		c          Conventions
+		lengths    []int
+		messages   []string
+		types      = make(map[string]int)
+		gitmojis   = make(map[string]int)
+		scopes     = make(map[string]int)
+		convCount  int
+		emojiCount int
+	)
+
+	for _, commit := range commits {
+		subject := strings.TrimSpace(commit.Subject())
+		if commit.Merge || subject == "" || strings.HasPrefix(subject, "Revert ") {
+			continue
+		}
+		c.Commits++
+		lengths = append(lengths, len([]rune(subject)))
+		messages = append(messages, commit.Message)
+
+		if match := conventionalHeader.FindStringSubmatch(subject); match != nil && isCommitType(strings.ToLower(match[1])) {
+			convCount++
+			types[strings.ToLower(match[1])]++
+			if match[2] != "" {
+				scopes[match[2]]++
+			}
+			continue
+		}
+
+		first, _, _ := strings.Cut(subject, " ")
+		if g, ok := prompt.LookupGitmoji(first); ok {
+			emojiCount++
+			gitmojis[g.Emoji]++
+		}
+	}
+
+	if c.Commits == 0 {
+		return c
+	}
+
+	// A style counts once at least half of the commits follow it
+	switch {
+	case convCount*2 >= c.Commits:
+		c.Style = prompt.Conventional
+		c.Types = byFrequency(types, 1)
+		c.Scopes = byFrequency(scopes, 2)
+		if len(c.Scopes) > maxScopes {
+			c.Scopes = c.Scopes[:maxScopes]
+		}
+	case emojiCount*2 >= c.Commits:
+		c.Style = prompt.Gitmoji
+		c.Types = byFrequency(gitmojis, 1)
+	default:
+		c.Style = prompt.Empty
+	}
+
+	c.MaxLength = min(max(percentile(lengths, 90), 10), 200)
+	c.Locale = dominantLocale(messages)
+	return c
+}
+
+// Values returns the configuration keys matching the conventions, or nil when
+// too few commits were analysed to tell. The detected types are not among
+// them: a history rarely uses every type, and limiting commit_types to the
+// ones seen so far would reject the first commit of any other type.
+func (c Conventions) Values() map[string]string {
+	if c.Commits < MinCommits {
+		return nil
+	}
+
+	values := map[string]string{
+		"commit_style":      "plain",
+		"prompt_locale":     c.Locale.String(),
+		"prompt_max_length": strconv.Itoa(c.MaxLength),
+	}
+	if c.Style != prompt.Empty {
+		values["commit_style"] = string(c.Style)
+	}
+	if len(c.Scopes) > 0 {
+		values["commit_scopes"] = strings.Join(c.Scopes, ",")
+	}
+	return values
+}
+
+// isCommitType reports whether value is a conventional commit type.
+func isCommitType(value string) bool {
+	for _, t := range prompt.CommitTypes() {
+		if value == t.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// byFrequency returns the keys seen at least minCount times, most common first.
+func byFrequency(counts map[string]int, minCount int) []string {
+	var keys []string
+	for key, n := range counts {
+		if n >= minCount {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// percentile returns the p-th percentile of values using the nearest-rank method.
+func percentile(values []int, p int) int {
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank-1, 0)]
+}
@@ -0,0 +1,191 @@
+package detect
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tolgaOzen/combo/pkg/git"
+	"github.com/tolgaOzen/combo/pkg/prompt"
+)
+
+// history returns a commit for each message, newest first. Messages starting
+// with "Merge " are merge commits.
+func history(messages ...string) []git.Commit {
+	commits := make([]git.Commit, len(messages))
+	for i, message := range messages {
+		commits[i] = git.Commit{Hash: fmt.Sprintf("%040d", i), Message: message, Merge: strings.HasPrefix(message, "Merge ")}
+	}
+	return commits
+}
+
+// repeat returns n copies of each message.
+func repeat(n int, messages ...string) []string {
+	var result []string
+	for i := 0; i < n; i++ {
+		result = append(result, messages...)
+	}
+	return result
+}
+
+func TestAnalyse(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []string
+		want     Conventions
+	}{
+		{
+			name: "conventional",
+			messages: append(repeat(3,
+				"feat(api): add the users endpoint",
+				"fix(api): handle a missing user",
+				"feat(ui): show the user list",
+				"docs: describe the API"),
+				"chore(deps): bump x",
+				"Update README"),
+			want: Conventions{
+				Commits:   14,
+				Style:     prompt.Conventional,
+				Types:     []string{"feat", "docs", "fix", "chore"},
+				Scopes:    []string{"api", "ui"},
+				MaxLength: 33,
+				Locale:    prompt.EnUS,
+			},
+		},
+		{
+			name: "gitmoji",
+			messages: repeat(4,
+				"✨ add the users endpoint",
+				":bug: handle a missing user",
+				"✨ show the user list"),
+			want: Conventions{
+				Commits:   12,
+				Style:     prompt.Gitmoji,
+				Types:     []string{"✨", "🐛"},
+				MaxLength: 27,
+				Locale:    prompt.EnUS,
+			},
+		},
+		{
+			name: "plain",
+			messages: append(repeat(5,
+				"Add the users endpoint",
+				"Handle a missing user"),
+				"feat: one conventional commit"),
+			want: Conventions{
+				Commits:   11,
+				Style:     prompt.Empty,
+				MaxLength: 22, // nine in ten subjects
+				Locale:    prompt.EnUS,
+			},
+		},
+		{
+			name: "merges and reverts are skipped",
+			messages: append(repeat(2,
+				"Fix the build",
+				"Merge pull request #1 from x/y",
+				"Revert \"feat: add the users endpoint\""),
+				""),
+			want: Conventions{
+				Commits:   2,
+				Style:     prompt.Empty,
+				MaxLength: 13,
+				Locale:    prompt.EnUS,
+			},
+		},
+		{
+			name: "locale",
+			messages: repeat(4,
+				"fix: corrige le calcul de la taille des fichiers",
+				"feat: ajoute une option pour les couleurs"),
+			want: Conventions{
+				Commits:   8,
+				Style:     prompt.Conventional,
+				Types:     []string{"feat", "fix"},
+				MaxLength: 48,
+				Locale:    prompt.FrFR,
+			},
+		},
+		{name: "empty", want: Conventions{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Analyse(history(tt.messages...)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Analyse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyseScopes(t *testing.T) {
+	var messages []string
+	for i := 0; i < 20; i++ {
+		// Scopes used once are left out, the others are capped
+		messages = append(messages, fmt.Sprintf("fix(s%02d): a", i), fmt.Sprintf("fix(s%02d): b", i), fmt.Sprintf("fix(once%02d): c", i))
+	}
+	got := Analyse(history(messages...)).Scopes
+	if len(got) != maxScopes || got[0] != "s00" || got[maxScopes-1] != fmt.Sprintf("s%02d", maxScopes-1) {
+		t.Errorf("Scopes = %q", got)
+	}
+}
+
+func TestValues(t *testing.T) {
+	messages := repeat(MinCommits, "feat(api): add the users endpoint")
+
+	if got := Analyse(history(messages[:MinCommits-1]...)).Values(); got != nil {
+		t.Errorf("Values() with %d commits = %v, want nil", MinCommits-1, got)
+	}
+
+	want := map[string]string{
+		"commit_style":      "conventional",
+		"commit_scopes":     "api",
+		"prompt_locale":     "en-US",
+		"prompt_max_length": "33",
+	}
+	if got := Analyse(history(messages...)).Values(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Values() = %v, want %v", got, want)
+	}
+
+	plain := Analyse(history(repeat(MinCommits, "Add the users endpoint")...)).Values()
+	if plain["commit_style"] != "plain" || plain["commit_types"] != "" {
+		t.Errorf("Values() of a plain history = %v", plain)
+	}
+}
+
+func TestMessageLocale(t *testing.T) {
+	tests := []struct {
+		message string
+		want    prompt.Locale
+	}{
+		{message: "fix: handle the missing config file", want: prompt.EnUS},
+		{message: "", want: prompt.EnUS},
+		{message: "1234 !!", want: prompt.EnUS},
+		{message: "feat: 設定ファイルの読み込みを追加", want: prompt.JaJP},
+		{message: "fix: 修正する", want: prompt.JaJP},
+		{message: "feat: 新增設定檔的錯誤處理", want: prompt.ZhTW},
+		{message: "feat: 新增设置文件的错误处理", want: prompt.ZhCN},
+		{message: "修复", want: prompt.ZhCN},
+		{message: "feat: 설정 파일 읽기 추가", want: prompt.KoKR},
+		{message: "fix: исправить чтение файла", want: prompt.RuRU},
+		{message: "fix: corrige le calcul de la taille", want: prompt.FrFR},
+		{message: "fix: Fehler bei der Anzeige behoben", want: prompt.DeDE},
+		// A few CJK characters in an English message do not make it Chinese
+		{message: "docs: describe the translation of the word 錯誤 in the glossary", want: prompt.EnUS},
+	}
+	for _, tt := range tests {
+		if got := messageLocale(tt.message); got != tt.want {
+			t.Errorf("messageLocale(%q) = %s, want %s", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestDominantLocale(t *testing.T) {
+	messages := []string{"feat: 新增設定檔", "fix: 修正錯誤", "fix: handle nil", "feat: 新增功能"}
+	if got := dominantLocale(messages); got != prompt.ZhTW {
+		t.Errorf("dominantLocale() = %s, want %s", got, prompt.ZhTW)
+	}
+	if got := dominantLocale(nil); got != prompt.EnUS {
+		t.Errorf("dominantLocale(nil) = %s, want %s", got, prompt.EnUS)
+	}
+}
@@ -0,0 +1,116 @@
+package detect
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/tolgaOzen/combo/pkg/prompt"
+)
+
+// stopwords are common words of the Latin-script locales, used to tell them apart.
+var stopwords = map[prompt.Locale][]string{
+	prompt.EnUS: {"the", "and", "to", "of", "in", "for", "with", "add", "fix", "remove", "update", "use", "when"},
+	prompt.FrFR: {"le", "la", "les", "des", "du", "et", "pour", "ajout", "ajoute", "corrige", "correction", "mise", "une", "dans"},
+	prompt.EsES: {"el", "los", "las", "del", "y", "para", "agrega", "añade", "corrige", "una", "por", "en", "se"},
+	prompt.DeDE: {"der", "die", "das", "und", "für", "mit", "nicht", "hinzugefügt", "behoben", "von", "zu", "ein", "eine"},
+	prompt.ItIT: {"il", "gli", "della", "per", "aggiunto", "aggiunta", "corretto", "una", "di", "che", "nel"},
+	prompt.PtBR: {"o", "os", "da", "do", "das", "dos", "para", "com", "adiciona", "corrige", "uma", "não", "em"},
+}
+
+// traditionalHan and simplifiedHan are common characters that differ between
+// Traditional and Simplified Chinese.
+const (
+	traditionalHan = "這個們對說會來時為國與後過還發開關點體檔專設錯誤"
+	simplifiedHan  = "这个们对说会来时为国与后过还发开关点体档专设错误"
+)
+
+// dominantLocale returns the locale most of the messages are written in.
+func dominantLocale(messages []string) prompt.Locale {
+	counts := make(map[prompt.Locale]int)
+	for _, message := range messages {
+		counts[messageLocale(message)]++
+	}
+
+	best := prompt.EnUS
+	for _, locale := range prompt.SupportedLocales() {
+		if counts[locale] > counts[best] {
+			best = locale
+		}
+	}
+	return best
+}
+
+// messageLocale guesses the locale of a single message, first by script and
+// then, for Latin script, by its most frequent stopwords.
+func messageLocale(message string) prompt.Locale {
+	var letters, kana, hangul, han, cyrillic, arabic, devanagari, traditional, simplified int
+	for _, r := range message {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Han, r):
+			han++
+			if strings.ContainsRune(traditionalHan, r) {
+				traditional++
+			}
+			if strings.ContainsRune(simplifiedHan, r) {
+				simplified++
+			}
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		case unicode.Is(unicode.Devanagari, r):
+			devanagari++
+		}
+	}
+	if letters == 0 {
+		return prompt.EnUS
+	}
+
+	// A script counts once a fifth of the letters use it, since code
+	// identifiers and commit types are usually in English
+	significant := func(n int) bool { return n*5 >= letters }
+	switch {
+	case kana > 0 && significant(kana+han):
+		return prompt.JaJP
+	case significant(hangul):
+		return prompt.KoKR
+	case significant(han):
+		if traditional > simplified {
+			return prompt.ZhTW
+		}
+		return prompt.ZhCN
+	case significant(cyrillic):
+		return prompt.RuRU
+	case significant(arabic):
+		return prompt.ArSA
+	case significant(devanagari):
+		return prompt.HiIN
+	}
+
+	counts := make(map[prompt.Locale]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(message), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		for locale, words := range stopwords {
+			for _, w := range words {
+				if word == w {
+					counts[locale]++
+				}
+			}
+		}
+	}
+
+	best := prompt.EnUS
+	for _, locale := range prompt.SupportedLocales() {
+		if counts[locale] > counts[best] {
+			best = locale
+		}
+	}
+	return best
+}
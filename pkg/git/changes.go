@@ -0,0 +1,203 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// File modes recorded by git.
+const (
+	modeFile       = "100644"
+	modeExecutable = "100755"
+	modeSymlink    = "120000"
+	modeSubmodule  = "160000"
+)
+
+// zeroHash is the object name git reports for a missing side of a change.
+const zeroHash = "0000000000000000000000000000000000000000"
+
+// FileChange describes how one staged file changed. Modes and object names
+// are unknown for files renamed or copied without changes.
+type FileChange struct {
+	Status     byte   // A, C, D, M, R or T, as in git diff --raw
+	Path       string // path in the index
+	OldPath    string // path in HEAD; differs from Path for renames and copies
+	OldMode    string
+	NewMode    string
+	OldHash    string
+	NewHash    string
+	Similarity int // percentage, for renames and copies
+	Binary     bool
+	Additions  int
+	Deletions  int
+	Hunks      []Hunk
+	Patch      string // the file's section of the patch
+}
+
+// Describe explains what a plain patch shows poorly or not at all: renames,
+// copies, mode changes, symlinks, submodules and binary files. It returns ""
+// for ordinary content changes.
+func (c FileChange) Describe() (string, error) {
+	var parts []string
+	switch c.Status {
+	case 'R':
+		parts = append(parts, fmt.Sprintf("renamed %s -> %s (%d%% similar)", c.OldPath, c.Path, c.Similarity))
+	case 'C':
+		parts = append(parts, fmt.Sprintf("copied %s -> %s (%d%% similar)", c.OldPath, c.Path, c.Similarity))
+	}
+
+	switch {
+	case c.OldMode == modeSubmodule || c.NewMode == modeSubmodule:
+		parts = append(parts, describeSubmodule(c))
+	case c.OldMode == modeSymlink || c.NewMode == modeSymlink:
+		description, err := describeSymlink(c)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, description)
+	case c.Binary:
+		description, err := describeBinary(c)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, description)
+	case c.Status != 'A' && c.Status != 'D' && c.OldMode != c.NewMode:
+		parts = append(parts, describeMode(c))
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// describeMode explains a change of file mode.
+func describeMode(c FileChange) string {
+	switch {
+	case c.OldMode == modeFile && c.NewMode == modeExecutable:
+		return fmt.Sprintf("made %s executable", c.Path)
+	case c.OldMode == modeExecutable && c.NewMode == modeFile:
+		return fmt.Sprintf("made %s non-executable", c.Path)
+	}
+	return fmt.Sprintf("changed mode of %s from %s to %s", c.Path, c.OldMode, c.NewMode)
+}
+
+// describeSymlink explains a change involving a symbolic link.
+func describeSymlink(c FileChange) (string, error) {
+	target := func(hash string) (string, error) {
+		out, err := current.ReadBlob(hash)
+		if err != nil {
+			return "", fmt.Errorf("failed to read symlink %s: %w", c.Path, err)
+		}
+		return string(out), nil
+	}
+
+	switch {
+	case c.Status == 'D':
+		return fmt.Sprintf("removed symlink %s", c.OldPath), nil
+	case c.NewMode != modeSymlink:
+		return fmt.Sprintf("replaced symlink %s with a regular file", c.Path), nil
+	}
+
+	newTarget, err := target(c.NewHash)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case c.Status == 'A':
+		return fmt.Sprintf("added symlink %s -> %s", c.Path, newTarget), nil
+	case c.OldMode != modeSymlink:
+		return fmt.Sprintf("replaced %s with a symlink to %s", c.Path, newTarget), nil
+	}
+	oldTarget, err := target(c.OldHash)
+	if err != nil {
+		return "", err
+	}
+	if oldTarget == newTarget {
+		return fmt.Sprintf("symlink %s -> %s", c.Path, newTarget), nil
+	}
+	return fmt.Sprintf("pointed symlink %s to %s instead of %s", c.Path, newTarget, oldTarget), nil
+}
+
+// describeSubmodule explains a change to a submodule.
+func describeSubmodule(c FileChange) string {
+	switch {
+	case c.Status == 'A' || c.OldMode != modeSubmodule:
+		return fmt.Sprintf("added submodule %s at %.7s", c.Path, c.NewHash)
+	case c.Status == 'D' || c.NewMode != modeSubmodule:
+		return fmt.Sprintf("removed submodule %s", c.OldPath)
+	}
+	return fmt.Sprintf("moved submodule %s from %.7s to %.7s", c.Path, c.OldHash, c.NewHash)
+}
+
+// describeBinary explains a change to a binary file by its size.
+func describeBinary(c FileChange) (string, error) {
+	oldSize, err := blobSize(c.OldHash)
+	if err != nil {
+		return "", err
+	}
+	newSize, err := blobSize(c.NewHash)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case c.OldHash == "" && c.NewHash == "":
+		return fmt.Sprintf("binary %s", c.Path), nil
+	case c.Status == 'A':
+		return fmt.Sprintf("added binary %s (%s)", c.Path, formatSize(newSize)), nil
+	case c.Status == 'D':
+		return fmt.Sprintf("removed binary %s (%s)", c.OldPath, formatSize(oldSize)), nil
+	}
+	if oldSize == newSize && c.OldHash == c.NewHash {
+		return fmt.Sprintf("binary %s (%s)", c.Path, formatSize(newSize)), nil
+	}
+	delta := formatSize(abs(newSize - oldSize))
+	sign := "+"
+	if newSize < oldSize {
+		sign = "-"
+	}
+	return fmt.Sprintf("changed binary %s: %s -> %s (%s%s)", c.Path, formatSize(oldSize), formatSize(newSize), sign, delta), nil
+}
+
+// blobSize returns the size of a blob in bytes, or 0 for a missing side.
+func blobSize(hash string) (int64, error) {
+	if hash == "" || hash == zeroHash {
+		return 0, nil
+	}
+	return current.BlobSize(hash)
+}
+
+// formatSize formats a size in bytes for people.
+func formatSize(size int64) string {
+	switch {
+	case size < 1024:
+		return fmt.Sprintf("%d B", size)
+	case size < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	}
+	return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+}
+
+// abs returns the absolute value of n.
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// DescribeChanges lists the descriptions of the changes that have one, or
+// returns "" if every change is an ordinary content change.
+func DescribeChanges(changes []FileChange) (string, error) {
+	var lines []string
+	for _, change := range changes {
+		description, err := change.Describe()
+		if err != nil {
+			return "", err
+		}
+		if description != "" {
+			lines = append(lines, "- "+description)
+		}
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	return "File changes not shown in full by the patch:\n" + strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,267 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DiffResult is a staged change, parsed from a single git diff invocation.
+type DiffResult struct {
+	Base  string // revision the index was compared with, usually HEAD
+	Files []FileChange
+}
+
+// Hunk is one block of changed lines in the patch of a file.
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Section  string   // text after the range, usually the enclosing function
+	Lines    []string // lines with their " ", "+", "-" or "\" prefix
+}
+
+// ParseDiff reads the output of git diff -z --numstat --patch: one numstat
+// entry per file, an empty entry, then the patch of every file in the same order.
+func ParseDiff(out string) (*DiffResult, error) {
+	result := &DiffResult{}
+	rest := out
+	for rest != "" {
+		var token string
+		token, rest = nextToken(rest)
+		if token == "" {
+			break
+		}
+
+		// added<TAB>deleted<TAB>path, where the path is empty for renames and
+		// copies and the two paths follow
+		parts := strings.SplitN(token, "\t", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("unexpected numstat entry %q", token)
+		}
+		change := FileChange{Status: 'M', Path: parts[2], OldPath: parts[2]}
+		if parts[2] == "" {
+			change.OldPath, rest = nextToken(rest)
+			change.Path, rest = nextToken(rest)
+		}
+		if parts[0] == "-" && parts[1] == "-" {
+			change.Binary = true
+		} else {
+			change.Additions, _ = strconv.Atoi(parts[0])
+			change.Deletions, _ = strconv.Atoi(parts[1])
+		}
+		result.Files = append(result.Files, change)
+	}
+
+	sections := splitPatch(rest)
+	file := -1
+	previousHeader := ""
+	for _, section := range sections {
+		header, _, _ := strings.Cut(section, "\n")
+		// A type change, such as a file replaced by a symlink, is patched as a
+		// deletion and an addition of the same path but counted once
+		if file >= 0 && header == previousHeader && result.Files[file].Status == 'D' {
+			result.Files[file].merge(section)
+			continue
+		}
+
+		file++
+		if file >= len(result.Files) {
+			return nil, fmt.Errorf("unexpected patch for %q", header)
+		}
+		result.Files[file].parsePatch(section)
+		previousHeader = header
+	}
+	return result, nil
+}
+
+// nextToken splits off the text up to the next NUL.
+func nextToken(s string) (string, string) {
+	token, rest, _ := strings.Cut(s, "\x00")
+	return token, rest
+}
+
+// splitPatch splits a patch into the sections of each file.
+func splitPatch(patch string) []string {
+	var sections []string
+	for patch != "" {
+		end := strings.Index(patch, "\ndiff --git ")
+		if end < 0 {
+			sections = append(sections, patch)
+			break
+		}
+		sections = append(sections, patch[:end+1])
+		patch = patch[end+1:]
+	}
+	return sections
+}
+
+// hunkHeader matches "@@ -old,lines +new,lines @@ section".
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+
+// parsePatch reads the extended headers and hunks of the file's patch section.
+func (c *FileChange) parsePatch(section string) {
+	c.Patch = section
+	lines := strings.Split(strings.TrimSuffix(section, "\n"), "\n")
+	for _, line := range lines[1:] {
+		if match := hunkHeader.FindStringSubmatch(line); match != nil {
+			c.Hunks = append(c.Hunks, Hunk{
+				OldStart: atoi(match[1]),
+				OldLines: hunkLines(match[2]),
+				NewStart: atoi(match[3]),
+				NewLines: hunkLines(match[4]),
+				Section:  match[5],
+			})
+			continue
+		}
+		if len(c.Hunks) > 0 {
+			hunk := &c.Hunks[len(c.Hunks)-1]
+			hunk.Lines = append(hunk.Lines, line)
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "new file mode "):
+			c.Status, c.OldMode, c.NewMode = 'A', "000000", strings.TrimPrefix(line, "new file mode ")
+		case strings.HasPrefix(line, "deleted file mode "):
+			c.Status, c.OldMode, c.NewMode = 'D', strings.TrimPrefix(line, "deleted file mode "), "000000"
+		case strings.HasPrefix(line, "old mode "):
+			c.OldMode = strings.TrimPrefix(line, "old mode ")
+		case strings.HasPrefix(line, "new mode "):
+			c.NewMode = strings.TrimPrefix(line, "new mode ")
+		case strings.HasPrefix(line, "rename from "):
+			c.Status = 'R'
+		case strings.HasPrefix(line, "copy from "):
+			c.Status = 'C'
+		case strings.HasPrefix(line, "similarity index "):
+			c.Similarity = atoi(strings.TrimSuffix(strings.TrimPrefix(line, "similarity index "), "%"))
+		case strings.HasPrefix(line, "index "):
+			// index <old>..<new>, followed by the mode if it did not change
+			fields := strings.Fields(strings.TrimPrefix(line, "index "))
+			c.OldHash, c.NewHash, _ = strings.Cut(fields[0], "..")
+			if len(fields) > 1 {
+				c.OldMode, c.NewMode = fields[1], fields[1]
+			}
+		}
+	}
+}
+
+// merge folds the addition half of a type change into the deletion half.
+func (c *FileChange) merge(section string) {
+	added := FileChange{}
+	added.parsePatch(section)
+	c.Status = 'T'
+	c.NewMode, c.NewHash = added.NewMode, added.NewHash
+	c.Hunks = append(c.Hunks, added.Hunks...)
+	c.Patch += section
+}
+
+// atoi converts a number known to consist of digits.
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// hunkLines reads the optional line count of a hunk range, which defaults to 1.
+func hunkLines(s string) int {
+	if s == "" {
+		return 1
+	}
+	return atoi(s)
+}
+
+// Paths returns the paths of the changed files.
+func (r *DiffResult) Paths() []string {
+	paths := make([]string, len(r.Files))
+	for i, file := range r.Files {
+		paths[i] = file.Path
+	}
+	return paths
+}
+
+// Filter returns the result limited to the files for which keep is true.
+func (r *DiffResult) Filter(keep func(FileChange) bool) *DiffResult {
+	filtered := &DiffResult{Base: r.Base}
+	for _, file := range r.Files {
+		if keep(file) {
+			filtered.Files = append(filtered.Files, file)
+		}
+	}
+	return filtered
+}
+
+// fullIndex matches the full object names of an index line, which cost tokens
+// without telling the model anything.
+var fullIndex = regexp.MustCompile(`(?m)^index ([0-9a-f]{7})[0-9a-f]*\.\.([0-9a-f]{7})[0-9a-f]*`)
+
+// Patch returns the patch of every file, with abbreviated object names.
+func (r *DiffResult) Patch() string {
+	var b strings.Builder
+	for _, file := range r.Files {
+		b.WriteString(file.Patch)
+	}
+	return fullIndex.ReplaceAllString(b.String(), "index $1..$2")
+}
+
+// Stat summarises the changed files and line counts, like git diff --compact-summary.
+func (r *DiffResult) Stat() string {
+	names := make([]string, len(r.Files))
+	width := 0
+	for i, file := range r.Files {
+		name := file.Path
+		switch file.Status {
+		case 'A':
+			name += " (new)"
+		case 'D':
+			name += " (gone)"
+		case 'R', 'C':
+			name = file.OldPath + " => " + file.Path
+		}
+		if file.OldMode != file.NewMode && file.Status != 'A' && file.Status != 'D' {
+			switch file.NewMode {
+			case modeExecutable:
+				name += " (mode +x)"
+			case modeFile:
+				name += " (mode -x)"
+			}
+		}
+
+		names[i] = name
+		width = max(width, len(name))
+	}
+
+	var b strings.Builder
+	additions, deletions := 0, 0
+	for i, file := range r.Files {
+		counts := "Bin"
+		if !file.Binary {
+			counts = fmt.Sprintf("+%d -%d", file.Additions, file.Deletions)
+		}
+		fmt.Fprintf(&b, " %-*s | %s\n", width, names[i], counts)
+		additions += file.Additions
+		deletions += file.Deletions
+	}
+	fmt.Fprintf(&b, " %d files changed, %d insertions(+), %d deletions(-)\n", len(r.Files), additions, deletions)
+	return b.String()
+}
+
+// Render formats the change for the model: the stat and the patch, truncated
+// to MaxDiffSize, preceded by the descriptions of renames, modes and binaries,
+// which truncation must not hide.
+func (r *DiffResult) Render() (string, error) {
+	diff := r.Stat() + "\n" + r.Patch()
+	if len(diff) > MaxDiffSize {
+		diff = diff[:MaxDiffSize] + "\n[...truncated]"
+	}
+
+	description, err := DescribeChanges(r.Files)
+	if err != nil {
+		return "", err
+	}
+	if description != "" {
+		diff = description + "\n\n" + diff
+	}
+	return diff, nil
+}
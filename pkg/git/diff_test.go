@@ -0,0 +1,151 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The outputs below were recorded from git diff --cached -z --numstat --patch
+// -M -C --full-index, with long hunks shortened.
+func TestParseDiff(t *testing.T) {
+	tests := []struct {
+		name  string
+		out   string
+		want  []FileChange // without Hunks and Patch
+		hunks []int        // number of hunks of each file
+	}{
+		{
+			name: "mode change, binary and exact rename",
+			out: "0\t0\ta\x00-\t-\tbin\x000\t0\t\x00m\x00mm\x00\x00" +
+				"diff --git a/a b/a\nold mode 100644\nnew mode 100755\n" +
+				"diff --git a/bin b/bin\nindex 88768efdf77ec78c9a995f94881793be6a41752b..3e3315e1b02129d197721a8a0b56dd88862f454d 100644\nBinary files a/bin and b/bin differ\n" +
+				"diff --git a/m b/mm\nsimilarity index 100%\nrename from m\nrename to mm\n",
+			want: []FileChange{
+				{Status: 'M', Path: "a", OldPath: "a", OldMode: "100644", NewMode: "100755"},
+				{Status: 'M', Path: "bin", OldPath: "bin", OldMode: "100644", NewMode: "100644",
+					OldHash: "88768efdf77ec78c9a995f94881793be6a41752b", NewHash: "3e3315e1b02129d197721a8a0b56dd88862f454d", Binary: true},
+				{Status: 'R', Path: "mm", OldPath: "m", Similarity: 100},
+			},
+			hunks: []int{0, 0, 0},
+		},
+		{
+			name: "rename with edits",
+			out: "1\t0\t\x00r\x00r2\x00\x00" +
+				"diff --git a/r b/r2\nsimilarity index 79%\nrename from r\nrename to r2\nindex f384549cbeb481e437091320de6d1f2e15e11b4a..b2f931a67315c95c5daab3aac6de62e534808476 100644\n--- a/r\n+++ b/r2\n@@ -2,3 +2,4 @@ one\n two\n three\n four\n+five\n",
+			want: []FileChange{
+				{Status: 'R', Path: "r2", OldPath: "r", OldMode: "100644", NewMode: "100644",
+					OldHash: "f384549cbeb481e437091320de6d1f2e15e11b4a", NewHash: "b2f931a67315c95c5daab3aac6de62e534808476", Similarity: 79, Additions: 1},
+			},
+			hunks: []int{1},
+		},
+		{
+			name: "copies from a deleted file",
+			out: "0\t0\t\x00a\x00b\x000\t0\t\x00a\x00c\x00\x00" +
+				"diff --git a/a b/b\nsimilarity index 100%\ncopy from a\ncopy to b\n" +
+				"diff --git a/a b/c\nsimilarity index 100%\nrename from a\nrename to c\n",
+			want: []FileChange{
+				{Status: 'C', Path: "b", OldPath: "a", Similarity: 100},
+				{Status: 'R', Path: "c", OldPath: "a", Similarity: 100},
+			},
+			hunks: []int{0, 0},
+		},
+		{
+			name: "empty deletion, symlink and missing newline",
+			out: "1\t2\ta\x000\t0\te\x001\t0\tlnk\x00\x00" +
+				"diff --git a/a b/a\nindex 0ff3bbb9c8bba2291654cd64067fa417ff54c508..20cbb4d89224e1ed724b7feaf5c4f4479e25212a 100755\n--- a/a\n+++ b/a\n@@ -1,2 +1 @@\n-1\n-2\n+no newline\n\\ No newline at end of file\n" +
+				"diff --git a/e b/e\ndeleted file mode 100644\nindex e69de29bb2d1d6434b8b29ae775ad8c2e48c5391..0000000000000000000000000000000000000000\n" +
+				"diff --git a/lnk b/lnk\nnew file mode 120000\nindex 0000000000000000000000000000000000000000..2e65efe2a145dda7ee51d1741299f848e5bf752e\n--- /dev/null\n+++ b/lnk\n@@ -0,0 +1 @@\n+a\n\\ No newline at end of file\n",
+			want: []FileChange{
+				{Status: 'M', Path: "a", OldPath: "a", OldMode: "100755", NewMode: "100755",
+					OldHash: "0ff3bbb9c8bba2291654cd64067fa417ff54c508", NewHash: "20cbb4d89224e1ed724b7feaf5c4f4479e25212a", Additions: 1, Deletions: 2},
+				{Status: 'D', Path: "e", OldPath: "e", OldMode: "100644", NewMode: "000000",
+					OldHash: "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391", NewHash: zeroHash},
+				{Status: 'A', Path: "lnk", OldPath: "lnk", OldMode: "000000", NewMode: modeSymlink,
+					OldHash: zeroHash, NewHash: "2e65efe2a145dda7ee51d1741299f848e5bf752e", Additions: 1},
+			},
+			hunks: []int{1, 0, 1},
+		},
+		{
+			name: "type change",
+			out: "1\t1\tt\x00\x00" +
+				"diff --git a/t b/t\ndeleted file mode 100644\nindex 718f4d2ff533cf8ead8d3556cf43912bd245fbc4..0000000000000000000000000000000000000000\n--- a/t\n+++ /dev/null\n@@ -1 +0,0 @@\n-t\n" +
+				"diff --git a/t b/t\nnew file mode 120000\nindex 0000000000000000000000000000000000000000..2e65efe2a145dda7ee51d1741299f848e5bf752e\n--- /dev/null\n+++ b/t\n@@ -0,0 +1 @@\n+a\n\\ No newline at end of file\n",
+			want: []FileChange{
+				{Status: 'T', Path: "t", OldPath: "t", OldMode: "100644", NewMode: modeSymlink,
+					OldHash: "718f4d2ff533cf8ead8d3556cf43912bd245fbc4", NewHash: "2e65efe2a145dda7ee51d1741299f848e5bf752e", Additions: 1, Deletions: 1},
+			},
+			hunks: []int{2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseDiff(tt.out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Files) != len(tt.want) {
+				t.Fatalf("got %d files, want %d", len(result.Files), len(tt.want))
+			}
+
+			var patch string
+			for i, file := range result.Files {
+				patch += file.Patch
+				if len(file.Hunks) != tt.hunks[i] {
+					t.Errorf("%s: got %d hunks, want %d", file.Path, len(file.Hunks), tt.hunks[i])
+				}
+				file.Hunks, file.Patch = nil, ""
+				if !reflect.DeepEqual(file, tt.want[i]) {
+					t.Errorf("file %d:\ngot:  %+v\nwant: %+v", i, file, tt.want[i])
+				}
+			}
+
+			// The patch is split between the files without losing anything
+			if patch != patchOf(tt.out) {
+				t.Errorf("patches of the files do not add up to the patch:\n%q", patch)
+			}
+		})
+	}
+}
+
+// patchOf returns the patch of recorded output, after the empty entry ending the numstat.
+func patchOf(out string) string {
+	_, patch, _ := strings.Cut(out, "\x00\x00diff --git ")
+	return "diff --git " + patch
+}
+
+func TestParseDiffHunks(t *testing.T) {
+	out := "2\t1\tf\x00\x00" +
+		"diff --git a/f b/f\nindex 1111111111111111111111111111111111111111..2222222222222222222222222222222222222222 100644\n--- a/f\n+++ b/f\n" +
+		"@@ -1,2 +1,2 @@ func main() {\n-a\n+b\n c\n@@ -10 +10,2 @@\n x\n+y\n"
+	result, err := ParseDiff(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Hunk{
+		{OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 2, Section: "func main() {", Lines: []string{"-a", "+b", " c"}},
+		{OldStart: 10, OldLines: 1, NewStart: 10, NewLines: 2, Lines: []string{" x", "+y"}},
+	}
+	if got := result.Files[0].Hunks; !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseDiffErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+	}{
+		{"malformed numstat", "1 2 f\x00\x00"},
+		{"more patches than files", "1\t0\tf\x00\x00diff --git a/f b/f\n--- a/f\n+++ b/f\ndiff --git a/g b/g\n--- a/g\n+++ b/g\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseDiff(tt.out); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,471 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// execRepository implements Repository by running the git binary.
+type execRepository struct {
+	dir string // where git runs; "" is the process working directory
+}
+
+// NewExecRepository returns the repository containing dir, or the process
+// working directory when dir is empty, accessed through the git binary.
+func NewExecRepository(dir string) Repository {
+	return &execRepository{dir: dir}
+}
+
+// command prepares a Git command running in the repository's directory.
+func (r *execRepository) command(args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.dir
+	return cmd
+}
+
+// run executes a Git command and returns the output.
+func (r *execRepository) run(args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := r.command(args...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("git command failed: %s, error: %w", out.String(), err)
+	}
+
+	return out.String(), nil
+}
+
+// runAttached executes a Git command with its output going to the terminal.
+func (r *execRepository) runAttached(args ...string) error {
+	cmd := r.command(args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// RepoRoot returns the top-level directory of the current repository.
+func (r *execRepository) RepoRoot() (string, error) {
+	out, err := r.run("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("not inside a git repository: %w", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// CurrentBranch returns the short name of the checked out branch, or an empty string when HEAD is detached.
+func (r *execRepository) CurrentBranch() (string, error) {
+	out, err := r.run("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		// An unborn branch has no commit to resolve yet
+		out, err = r.run("symbolic-ref", "--short", "HEAD")
+		if err != nil {
+			return "", fmt.Errorf("failed to determine current branch: %w", err)
+		}
+	}
+
+	branch := strings.TrimSpace(out)
+	if branch == "HEAD" {
+		return "", nil
+	}
+	return branch, nil
+}
+
+// RevParse resolves a revision to its full commit hash.
+func (r *execRepository) RevParse(rev string) (string, error) {
+	out, err := r.run("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %s", rev)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// GitDir returns the absolute path of the git directory.
+func (r *execRepository) GitDir() (string, error) {
+	out, err := r.run("rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", fmt.Errorf("not inside a git repository: %w", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// StagedFiles returns the paths of the staged files.
+func (r *execRepository) StagedFiles() ([]string, error) {
+	out, err := r.run("diff", "--cached", "--name-only")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve staged file names: %w", err)
+	}
+
+	var files []string
+	for _, file := range strings.Split(out, "\n") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// UnstagedFiles returns the paths of the tracked files changed in the working tree but not staged.
+func (r *execRepository) UnstagedFiles() ([]string, error) {
+	out, err := r.run("diff", "--name-only", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve unstaged file names: %w", err)
+	}
+
+	var files []string
+	for _, file := range strings.Split(out, "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// Stage adds the working tree content of the given files to the index.
+func (r *execRepository) Stage(paths ...string) error {
+	if _, err := r.run(append([]string{"add", "--"}, paths...)...); err != nil {
+		return fmt.Errorf("failed to stage files: %w", err)
+	}
+	return nil
+}
+
+// DiffIndex parses the differences between base and the index from one
+// `git diff --cached -z --numstat --patch`, detecting renames and copies.
+func (r *execRepository) DiffIndex(base string, pathspecs ...string) (*DiffResult, error) {
+	tree := base
+	if _, err := r.RevParse(base); err != nil {
+		if tree, err = r.emptyTree(); err != nil {
+			return nil, err
+		}
+	}
+
+	args := []string{"diff", "--cached", "-z", "--numstat", "--patch", "-M", "-C", "--full-index", "--no-color", "--no-ext-diff", tree, "--"}
+	out, err := r.run(append(args, pathspecs...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve staged diff: %w", err)
+	}
+
+	result, err := ParseDiff(out)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Files) == 0 {
+		return nil, nil
+	}
+	result.Base = base
+	return result, nil
+}
+
+// emptyTree returns the object name of the empty tree in the repository's hash format.
+func (r *execRepository) emptyTree() (string, error) {
+	out, err := r.run("hash-object", "-t", "tree", "--stdin")
+	if err != nil {
+		return "", fmt.Errorf("failed to hash the empty tree: %w", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// ShowFile returns the content of path at rev, or in the index when rev is
+// empty. ok is false if the file does not exist there.
+func (r *execRepository) ShowFile(rev, path string) (content []byte, ok bool, err error) {
+	object := rev + ":" + path
+	if _, err := r.run("cat-file", "-e", object); err != nil {
+		return nil, false, nil
+	}
+
+	cmd := r.command("cat-file", "-p", object)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read %s: %s: %w", object, stderr.String(), err)
+	}
+	return out, true, nil
+}
+
+// ListFiles returns the paths of the files directly inside dir, relative to the
+// repository root, at rev or in the index when rev is empty.
+func (r *execRepository) ListFiles(rev, dir string) ([]string, error) {
+	pathspec := ":(top)" + dir
+	if path.Clean(dir) == "." {
+		pathspec = ":/"
+	}
+	args := []string{"ls-files", "-z", "--cached", "--full-name", "--", pathspec}
+	if rev != "" {
+		if _, err := r.RevParse(rev); err != nil {
+			return nil, nil
+		}
+		args = []string{"ls-tree", "-r", "-z", "--name-only", "--full-tree", rev, "--", dir}
+	}
+
+	out, err := r.run(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files in %s: %w", dir, err)
+	}
+
+	var files []string
+	for _, file := range strings.Split(out, "\x00") {
+		if file != "" && path.Dir(file) == path.Clean(dir) {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// ReadBlob returns the content of the blob with the given object name.
+func (r *execRepository) ReadBlob(hash string) ([]byte, error) {
+	out, err := r.run("cat-file", "-p", hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object %s: %w", hash, err)
+	}
+	return []byte(out), nil
+}
+
+// BlobSize returns the size in bytes of the blob with the given object name.
+func (r *execRepository) BlobSize(hash string) (int64, error) {
+	out, err := r.run("cat-file", "-s", hash)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the size of %s: %w", hash, err)
+	}
+	return strconv.ParseInt(strings.TrimSpace(out), 10, 64)
+}
+
+// ListCommits returns the commits in revRange, oldest first.
+func (r *execRepository) ListCommits(revRange string) ([]Commit, error) {
+	args := []string{"log", "--reverse", "--format=%H%x00%P%x00%B%x1e"}
+	if !strings.Contains(revRange, "..") {
+		args = append(args, "-1")
+	}
+	args = append(args, revRange, "--")
+
+	out, err := r.run(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits for %s: %w", revRange, err)
+	}
+
+	return parseCommits(out)
+}
+
+// RecentCommits returns up to n commits reachable from HEAD, newest first.
+func (r *execRepository) RecentCommits(n int) ([]Commit, error) {
+	if _, err := r.RevParse("HEAD"); err != nil {
+		return nil, nil
+	}
+
+	out, err := r.run("log", fmt.Sprintf("-n%d", n), "--format=%H%x00%P%x00%B%x1e", "HEAD", "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recent commits: %w", err)
+	}
+
+	return parseCommits(out)
+}
+
+// History returns up to n commits reachable from HEAD with the paths each one
+// touched, newest first.
+func (r *execRepository) History(n int) ([]Commit, error) {
+	if _, err := r.RevParse("HEAD"); err != nil {
+		return nil, nil
+	}
+
+	out, err := r.run("log", fmt.Sprintf("-n%d", n), "--name-only", "--format=%x1e%H%x00%P%x00%B%x00", "HEAD", "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit history: %w", err)
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+		fields := strings.SplitN(record, "\x00", 4)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected git log output: %q", record)
+		}
+
+		c := Commit{
+			Hash:    fields[0],
+			Message: strings.TrimSpace(fields[2]),
+			Merge:   len(strings.Fields(fields[1])) > 1,
+		}
+		for _, file := range strings.Split(fields[3], "\n") {
+			if file != "" {
+				c.Files = append(c.Files, file)
+			}
+		}
+		commits = append(commits, c)
+	}
+
+	return commits, nil
+}
+
+// parseCommits reads git log output in the "%H%x00%P%x00%B%x1e" format.
+func parseCommits(out string) ([]Commit, error) {
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, "\x00", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected git log output: %q", record)
+		}
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Message: strings.TrimSpace(fields[2]),
+			Merge:   len(strings.Fields(fields[1])) > 1,
+		})
+	}
+
+	return commits, nil
+}
+
+// CommitDiff returns the stat and patch of the changes introduced by rev.
+func (r *execRepository) CommitDiff(rev string) (string, error) {
+	return r.run("show", "--format=", "--patch", "--compact-summary", rev, "--")
+}
+
+// BranchDiff returns the stat and patch between the merge base of base and HEAD.
+func (r *execRepository) BranchDiff(base string) (string, error) {
+	return r.run("diff", "--patch", "--compact-summary", base+"...HEAD", "--")
+}
+
+// CommitStaged runs git commit, so that hooks and signing apply as configured.
+func (r *execRepository) CommitStaged(message string, opts CommitOptions) error {
+	args := []string{"commit", "-m", message}
+	if opts.Amend {
+		args = append(args, "--amend")
+	}
+	switch {
+	case opts.Sign && opts.SignKey != "":
+		args = append(args, "--gpg-sign="+opts.SignKey)
+	case opts.Sign:
+		args = append(args, "--gpg-sign")
+	}
+	if opts.NoVerify {
+		args = append(args, "--no-verify")
+	}
+	if opts.Author != "" {
+		args = append(args, "--author="+opts.Author)
+	}
+	if opts.Signoff {
+		args = append(args, "--signoff")
+	}
+	return r.runAttached(append(args, opts.Args...)...)
+}
+
+// CreateBranch runs git checkout -b.
+func (r *execRepository) CreateBranch(name string) error {
+	return r.runAttached("checkout", "-b", name)
+}
+
+// RewordCommits replaces the messages of the given commits, keyed by full hash.
+// HEAD alone is amended in place; anything older is rewritten with a scripted
+// rebase that amends each selected commit after it is picked. Hooks are skipped
+// because the content of the rewritten commits does not change. The todo list
+// is installed by running cp as the sequence editor, which git starts through
+// its POSIX shell: Git for Windows bundles both, other git builds without a
+// shell and cp cannot rewrite older commits.
+func (r *execRepository) RewordCommits(messages map[string]string) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	head, err := r.RevParse("HEAD")
+	if err != nil {
+		return err
+	}
+
+	if msg, ok := messages[head]; ok && len(messages) == 1 {
+		_, err := r.run("commit", "--amend", "--only", "--no-verify", "-m", msg)
+		return err
+	}
+
+	// Find the oldest commit to rewrite: every other one must descend from it.
+	var oldest string
+	for hash := range messages {
+		if _, err := r.run("merge-base", "--is-ancestor", hash, "HEAD"); err != nil {
+			return fmt.Errorf("commit %s is not an ancestor of HEAD", hash)
+		}
+		if oldest == "" {
+			oldest = hash
+			continue
+		}
+		if _, err := r.run("merge-base", "--is-ancestor", hash, oldest); err == nil {
+			oldest = hash
+		}
+	}
+
+	base := oldest + "^"
+	upstream := []string{base}
+	if _, err := r.RevParse(base); err != nil {
+		base, upstream = "", []string{"--root"}
+	}
+
+	todoRange := "HEAD"
+	if base != "" {
+		todoRange = base + "..HEAD"
+	}
+	merges, err := r.run("rev-list", "--merges", todoRange)
+	if err != nil {
+		return fmt.Errorf("failed to inspect history: %w", err)
+	}
+	if strings.TrimSpace(merges) != "" {
+		return fmt.Errorf("cannot reword across merge commits")
+	}
+
+	picks, err := r.run("rev-list", "--reverse", todoRange)
+	if err != nil {
+		return fmt.Errorf("failed to list commits to rebase: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "combo-reword-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var todo strings.Builder
+	for i, hash := range strings.Fields(picks) {
+		fmt.Fprintf(&todo, "pick %s\n", hash)
+		msg, ok := messages[hash]
+		if !ok {
+			continue
+		}
+		msgFile := filepath.Join(dir, fmt.Sprintf("msg-%d", i))
+		if err := os.WriteFile(msgFile, []byte(msg+"\n"), 0o600); err != nil {
+			return fmt.Errorf("failed to write commit message: %w", err)
+		}
+		fmt.Fprintf(&todo, "exec git commit --amend --only --no-verify -F %s\n", shellQuote(msgFile))
+	}
+
+	todoFile := filepath.Join(dir, "todo")
+	if err := os.WriteFile(todoFile, []byte(todo.String()), 0o600); err != nil {
+		return fmt.Errorf("failed to write rebase todo: %w", err)
+	}
+
+	args := append([]string{"rebase", "--interactive", "--autostash"}, upstream...)
+	cmd := r.command(args...)
+	cmd.Env = append(os.Environ(), "GIT_SEQUENCE_EDITOR=cp "+shellQuote(todoFile))
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("rebase failed (run `git rebase --abort` to restore): %s, error: %w", out.String(), err)
+	}
+
+	return nil
+}
+
+// shellQuote quotes s for use as a single POSIX shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -1,9 +1,8 @@
 package git
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
-	"os/exec"
 	"strings"
 )
 
@@ -11,11 +10,8 @@ const (
 	MaxDiffSize = 4096 // Limit to 4KB to prevent overwhelming LLM
 )
 
-// DiffResult encapsulates the staged diff results
-type DiffResult struct {
-	Files []string
-	Diff  string
-}
+// ErrNoStagedChanges is returned when there is nothing staged to describe.
+var ErrNoStagedChanges = errors.New("no staged changes found. Stage your changes manually, or use the `--all` flag")
 
 // GetDifferences retrieves staged differences, truncating if needed.
 func GetDifferences() (string, error) {
@@ -25,51 +21,22 @@ func GetDifferences() (string, error) {
 	}
 
 	if result == nil || len(result.Files) == 0 {
-		return "", fmt.Errorf("no staged changes found. Stage your changes manually, or use the `--all` flag")
-	}
-
-	diff := result.Diff
-	if len(diff) > MaxDiffSize {
-		diff = diff[:MaxDiffSize] + "\n[...truncated]"
+		return "", ErrNoStagedChanges
 	}
 
-	return diff, nil
+	return result.Render()
 }
 
-// FetchStagedDiff retrieves staged changes using `--patch --compact-summary` for better output.
-func FetchStagedDiff() (*DiffResult, error) {
-	filesOut, err := runGitCommand([]string{"diff", "--cached", "--name-only"})
-	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve staged file names: %w", err)
-	}
-
-	files := strings.Split(strings.TrimSpace(filesOut), "\n")
-	if len(files) == 1 && files[0] == "" {
-		return nil, nil
-	}
-
-	diffOut, err := runGitCommand([]string{"diff", "--cached", "--patch", "--compact-summary"})
-	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve staged diff summary: %w", err)
-	}
-
-	return &DiffResult{
-		Files: files,
-		Diff:  diffOut,
-	}, nil
+// Commit describes a single commit in the history.
+type Commit struct {
+	Hash    string
+	Message string
+	Merge   bool
+	Files   []string // Paths touched by the commit; only filled by History.
 }
 
-// runGitCommand executes a Git command and returns the output.
-func runGitCommand(args []string) (string, error) {
-	var out bytes.Buffer
-	cmd := exec.Command("git", args...)
-	cmd.Stdout = &out
-	cmd.Stderr = &out
-
-	err := cmd.Run()
-	if err != nil {
-		return "", fmt.Errorf("git command failed: %s, error: %w", out.String(), err)
-	}
-
-	return out.String(), nil
+// Subject returns the first line of the commit message.
+func (c Commit) Subject() string {
+	subject, _, _ := strings.Cut(c.Message, "\n")
+	return subject
 }
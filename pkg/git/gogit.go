@@ -0,0 +1,1026 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/go-git/go-git/v5/utils/binary"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// goGitRepository implements Repository in process with go-git, for systems
+// without a git binary. It does not run hooks or sign commits. Renames and
+// copies in the staged diff are detected like git diff -M -C would.
+type goGitRepository struct {
+	repo *gogit.Repository
+}
+
+// NewGoGitRepository wraps an opened go-git repository, which may be held in memory.
+func NewGoGitRepository(repo *gogit.Repository) Repository {
+	return &goGitRepository{repo: repo}
+}
+
+// OpenGoGitRepository opens the repository containing dir with go-git.
+func OpenGoGitRepository(dir string) (Repository, error) {
+	repo, err := gogit.PlainOpenWithOptions(dir, &gogit.PlainOpenOptions{DetectDotGit: true, EnableDotGitCommonDir: true})
+	if err != nil {
+		return nil, fmt.Errorf("not inside a git repository: %w", err)
+	}
+	return NewGoGitRepository(repo), nil
+}
+
+// RepoRoot returns the root of the repository's working tree.
+func (r *goGitRepository) RepoRoot() (string, error) {
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("not inside a git repository: %w", err)
+	}
+	return worktree.Filesystem.Root(), nil
+}
+
+// CurrentBranch returns the short name of the branch HEAD points to, born or not.
+func (r *goGitRepository) CurrentBranch() (string, error) {
+	head, err := r.repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return "", fmt.Errorf("failed to determine current branch: %w", err)
+	}
+	if head.Type() != plumbing.SymbolicReference {
+		return "", nil
+	}
+	return head.Target().Short(), nil
+}
+
+// RevParse resolves a revision to its full commit hash.
+func (r *goGitRepository) RevParse(rev string) (string, error) {
+	commit, err := r.commit(rev)
+	if err != nil {
+		return "", err
+	}
+	return commit.Hash.String(), nil
+}
+
+// commit resolves a revision to a commit.
+func (r *goGitRepository) commit(rev string) (*object.Commit, error) {
+	hash, err := r.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %s", rev)
+	}
+	commit, err := r.repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %s", rev)
+	}
+	return commit, nil
+}
+
+// tree returns the tree of rev, or nil if the revision does not exist yet.
+func (r *goGitRepository) tree(rev string) (*object.Tree, error) {
+	commit, err := r.commit(rev)
+	if err != nil {
+		return nil, nil
+	}
+	return commit.Tree()
+}
+
+// indexEntry is a file as recorded by a tree or the index.
+type indexEntry struct {
+	hash plumbing.Hash
+	mode filemode.FileMode
+}
+
+// treeEntries maps the paths of every file in the tree, including symlinks
+// and submodules, to their entries.
+func treeEntries(tree *object.Tree) (map[string]indexEntry, error) {
+	entries := make(map[string]indexEntry)
+	if tree == nil {
+		return entries, nil
+	}
+
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tree: %w", err)
+		}
+		if entry.Mode != filemode.Dir {
+			entries[name] = indexEntry{hash: entry.Hash, mode: entry.Mode}
+		}
+	}
+}
+
+// indexEntries maps the paths of the files in the index to their entries.
+// Conflicted paths, which have no single version, are left out.
+func (r *goGitRepository) indexEntries() (map[string]indexEntry, error) {
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the index: %w", err)
+	}
+
+	entries := make(map[string]indexEntry, len(idx.Entries))
+	for _, entry := range idx.Entries {
+		// Stage 0 holds the single version of a path; conflicts use 1 to 3
+		if entry.Stage == 0 {
+			entries[entry.Name] = indexEntry{hash: entry.Hash, mode: entry.Mode}
+		}
+	}
+	return entries, nil
+}
+
+// stagedPaths returns the paths that differ between base and the index,
+// sorted, with both sets of entries.
+func (r *goGitRepository) stagedPaths(base string) ([]string, map[string]indexEntry, map[string]indexEntry, error) {
+	tree, err := r.tree(base)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to read %s: %w", base, err)
+	}
+	old, err := treeEntries(tree)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	new, err := r.indexEntries()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var paths []string
+	for name, entry := range new {
+		if entry != old[name] {
+			paths = append(paths, name)
+		}
+	}
+	for name := range old {
+		if _, ok := new[name]; !ok {
+			paths = append(paths, name)
+		}
+	}
+	sort.Strings(paths)
+	return paths, old, new, nil
+}
+
+// GitDir returns the absolute path of the git directory. A repository kept
+// in memory has none.
+func (r *goGitRepository) GitDir() (string, error) {
+	storage, ok := r.repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return "", fmt.Errorf("the repository has no git directory")
+	}
+	return filepath.Abs(storage.Filesystem().Root())
+}
+
+// StagedFiles returns the paths of the staged files.
+func (r *goGitRepository) StagedFiles() ([]string, error) {
+	paths, _, _, err := r.stagedPaths("HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve staged file names: %w", err)
+	}
+	return paths, nil
+}
+
+// UnstagedFiles returns the paths of the tracked files changed in the working tree but not staged.
+func (r *goGitRepository) UnstagedFiles() ([]string, error) {
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve unstaged file names: %w", err)
+	}
+
+	var files []string
+	for file, s := range status {
+		if s.Worktree != gogit.Unmodified && s.Worktree != gogit.Untracked {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Stage adds the working tree content of the given files to the index.
+func (r *goGitRepository) Stage(paths ...string) error {
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	for _, file := range paths {
+		if _, err := worktree.Add(file); err != nil {
+			return fmt.Errorf("failed to stage %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// DiffIndex compares the index with base and renders the result in the
+// format of git diff -z --numstat --patch -M -C, so that it is parsed like the
+// output of the exec backend. Pathspecs are matched as path prefixes.
+func (r *goGitRepository) DiffIndex(base string, pathspecs ...string) (*DiffResult, error) {
+	paths, old, new, err := r.stagedPaths(base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve staged diff: %w", err)
+	}
+
+	changes, err := r.pairChanges(paths, old, new, pathspecs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect renames: %w", err)
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	var numstat, patch bytes.Buffer
+	for _, change := range changes {
+		if err := r.writeChange(&numstat, &patch, change); err != nil {
+			return nil, fmt.Errorf("failed to retrieve staged diff: %w", err)
+		}
+	}
+
+	numstat.WriteByte(0)
+	result, err := ParseDiff(numstat.String() + patch.String())
+	if err != nil {
+		return nil, err
+	}
+	result.Base = base
+	return result, nil
+}
+
+// stagedChange is one change between base and the index, as git diff reports it.
+type stagedChange struct {
+	status   byte
+	from, to *patchFile // nil for the missing side of an addition or deletion
+	score    int        // similarity of a rename or copy, up to maxScore
+}
+
+// pairChanges sorts the differing paths into the changes git diff reports,
+// in the same order: renames and copies in place of the added files, type
+// changes, and the remaining additions, deletions and modifications.
+func (r *goGitRepository) pairChanges(paths []string, old, new map[string]indexEntry, pathspecs []string) ([]stagedChange, error) {
+	var sources []*renameSource
+	var destinations []*renameDestination
+	sourceOf := make(map[string]*renameSource)
+	destinationOf := make(map[string]*renameDestination)
+	var names []string
+	for _, name := range paths {
+		if !matchPathspecs(name, pathspecs) {
+			continue
+		}
+		names = append(names, name)
+
+		from, hasFrom := old[name]
+		to, hasTo := new[name]
+		switch {
+		case !hasFrom:
+			destinationOf[name] = &renameDestination{path: name, entry: to}
+			destinations = append(destinations, destinationOf[name])
+		case !hasTo:
+			sourceOf[name] = &renameSource{path: name, entry: from, deleted: true}
+			sources = append(sources, sourceOf[name])
+		case sameType(from.mode, to.mode):
+			// A modified file stays, so it can only have been copied
+			sources = append(sources, &renameSource{path: name, entry: from})
+		}
+	}
+	if err := detectRenames(sources, destinations, r.read); err != nil {
+		return nil, err
+	}
+
+	// A deleted file renamed several times is copied to all but the last path
+	remaining := make(map[*renameSource]int)
+	for _, source := range sources {
+		remaining[source] = source.used
+	}
+
+	changes := make([]stagedChange, 0, len(names))
+	for _, name := range names {
+		from, hasFrom := old[name]
+		to, hasTo := new[name]
+		switch {
+		case !hasFrom:
+			destination := destinationOf[name]
+			change := stagedChange{status: 'A', to: newPatchFile(name, to)}
+			if destination.source >= 0 {
+				source := sources[destination.source]
+				remaining[source]--
+				change.status, change.from, change.score = 'C', newPatchFile(source.path, source.entry), destination.score
+				if source.deleted && remaining[source] == 0 {
+					change.status = 'R'
+				}
+			}
+			changes = append(changes, change)
+		case !hasTo:
+			if sourceOf[name].used == 0 {
+				changes = append(changes, stagedChange{status: 'D', from: newPatchFile(name, from)})
+			}
+		case sameType(from.mode, to.mode):
+			changes = append(changes, stagedChange{status: 'M', from: newPatchFile(name, from), to: newPatchFile(name, to)})
+		default:
+			changes = append(changes, stagedChange{status: 'T', from: newPatchFile(name, from), to: newPatchFile(name, to)})
+		}
+	}
+	return changes, nil
+}
+
+// writeChange appends the numstat entry and the patch of a change.
+func (r *goGitRepository) writeChange(numstat, out *bytes.Buffer, change stagedChange) error {
+	patch, err := r.filePatch(change.from, change.to)
+	if err != nil {
+		return err
+	}
+
+	counts := "-\t-"
+	if !patch.binary {
+		additions, deletions := patch.counts()
+		counts = fmt.Sprintf("%d\t%d", additions, deletions)
+	}
+	switch change.status {
+	case 'R', 'C':
+		fmt.Fprintf(numstat, "%s\t\x00%s\x00%s\x00", counts, change.from.path, change.to.path)
+	case 'D':
+		fmt.Fprintf(numstat, "%s\t%s\x00", counts, change.from.path)
+	default:
+		fmt.Fprintf(numstat, "%s\t%s\x00", counts, change.to.path)
+	}
+
+	// A type change is patched as a deletion followed by an addition
+	patches := []*filePatch{patch}
+	if change.status == 'T' {
+		deletion, err := r.filePatch(change.from, nil)
+		if err != nil {
+			return err
+		}
+		addition, err := r.filePatch(nil, change.to)
+		if err != nil {
+			return err
+		}
+		patches = []*filePatch{deletion, addition}
+	}
+
+	var encoded bytes.Buffer
+	for _, p := range patches {
+		if err := fdiff.NewUnifiedEncoder(&encoded, fdiff.DefaultContextLines).Encode(filePatches{p}); err != nil {
+			return fmt.Errorf("failed to encode staged diff: %w", err)
+		}
+	}
+
+	// The encoder describes every pair of paths as a rename, without its similarity
+	section := encoded.String()
+	if change.status == 'R' || change.status == 'C' {
+		kind := "rename"
+		if change.status == 'C' {
+			kind = "copy"
+		}
+		similarity := fmt.Sprintf("\nsimilarity index %d%%\n%s from ", change.score*100/maxScore, kind)
+		section = strings.Replace(section, "\nrename from ", similarity, 1)
+		section = strings.Replace(section, "\nrename to ", "\n"+kind+" to ", 1)
+	}
+	out.WriteString(section)
+	return nil
+}
+
+// matchPathspecs reports whether name equals or lies under one of the
+// pathspecs, or whether there are none.
+func matchPathspecs(name string, pathspecs []string) bool {
+	if len(pathspecs) == 0 {
+		return true
+	}
+	for _, spec := range pathspecs {
+		spec = strings.TrimSuffix(path.Clean(spec), "/")
+		if spec == "." || name == spec || strings.HasPrefix(name, spec+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// filePatch diffs two versions of a file line by line. Either may be nil for
+// an addition or a deletion.
+func (r *goGitRepository) filePatch(from, to *patchFile) (*filePatch, error) {
+	patch := &filePatch{from: from, to: to}
+	var oldContent, newContent string
+	if from != nil {
+		content, binary, err := r.content(from.entry())
+		if err != nil {
+			return nil, err
+		}
+		oldContent, patch.binary = content, binary
+	}
+	if to != nil {
+		content, binary, err := r.content(to.entry())
+		if err != nil {
+			return nil, err
+		}
+		newContent, patch.binary = content, patch.binary || binary
+	}
+
+	if !patch.binary {
+		for _, d := range diff.Do(oldContent, newContent) {
+			op := fdiff.Equal
+			switch d.Type {
+			case diffmatchpatch.DiffInsert:
+				op = fdiff.Add
+			case diffmatchpatch.DiffDelete:
+				op = fdiff.Delete
+			}
+			patch.chunks = append(patch.chunks, patchChunk{content: d.Text, op: op})
+		}
+	}
+	return patch, nil
+}
+
+// read returns the content of a file version and whether it is binary.
+func (r *goGitRepository) read(entry indexEntry) ([]byte, bool, error) {
+	data, err := r.ReadBlob(entry.hash.String())
+	if err != nil {
+		return nil, false, err
+	}
+	isBinary, err := binary.IsBinary(bytes.NewReader(data))
+	return data, err != nil || isBinary, nil
+}
+
+// content reads a file version as text, as git shows it in a patch:
+// submodules by their commit, and binary files not at all.
+func (r *goGitRepository) content(entry indexEntry) (string, bool, error) {
+	if entry.mode == filemode.Submodule {
+		return "Subproject commit " + entry.hash.String() + "\n", false, nil
+	}
+	data, isBinary, err := r.read(entry)
+	if err != nil || isBinary {
+		return "", isBinary, err
+	}
+	return string(data), false, nil
+}
+
+// filePatch is the change of one file between HEAD and the index.
+type filePatch struct {
+	from, to *patchFile
+	binary   bool
+	chunks   []fdiff.Chunk
+}
+
+func (p *filePatch) IsBinary() bool        { return p.binary }
+func (p *filePatch) Chunks() []fdiff.Chunk { return p.chunks }
+
+// Files returns the two versions, keeping a missing side a nil interface.
+func (p *filePatch) Files() (fdiff.File, fdiff.File) {
+	var from, to fdiff.File
+	if p.from != nil {
+		from = p.from
+	}
+	if p.to != nil {
+		to = p.to
+	}
+	return from, to
+}
+
+// counts returns the number of added and deleted lines.
+func (p *filePatch) counts() (additions, deletions int) {
+	for _, chunk := range p.chunks {
+		lines := strings.Count(chunk.Content(), "\n")
+		if !strings.HasSuffix(chunk.Content(), "\n") {
+			lines++
+		}
+		switch chunk.Type() {
+		case fdiff.Add:
+			additions += lines
+		case fdiff.Delete:
+			deletions += lines
+		}
+	}
+	return additions, deletions
+}
+
+// patchFile is one version of a file in a filePatch.
+type patchFile struct {
+	hash plumbing.Hash
+	mode filemode.FileMode
+	path string
+}
+
+// newPatchFile returns the version of the file at name recorded by entry.
+func newPatchFile(name string, entry indexEntry) *patchFile {
+	return &patchFile{hash: entry.hash, mode: entry.mode, path: name}
+}
+
+// entry returns the hash and mode of the version.
+func (f *patchFile) entry() indexEntry {
+	return indexEntry{hash: f.hash, mode: f.mode}
+}
+
+func (f *patchFile) Hash() plumbing.Hash     { return f.hash }
+func (f *patchFile) Mode() filemode.FileMode { return f.mode }
+func (f *patchFile) Path() string            { return f.path }
+
+// patchChunk is a run of equal, added or deleted text.
+type patchChunk struct {
+	content string
+	op      fdiff.Operation
+}
+
+func (c patchChunk) Content() string       { return c.content }
+func (c patchChunk) Type() fdiff.Operation { return c.op }
+
+// filePatches is a patch made of the given file patches.
+type filePatches []fdiff.FilePatch
+
+func (p filePatches) FilePatches() []fdiff.FilePatch { return p }
+func (p filePatches) Message() string                { return "" }
+
+// ShowFile returns the content of path at rev, or in the index when rev is
+// empty. ok is false if the file does not exist there.
+func (r *goGitRepository) ShowFile(rev, name string) ([]byte, bool, error) {
+	var hash plumbing.Hash
+	if rev == "" {
+		entries, err := r.indexEntries()
+		if err != nil {
+			return nil, false, err
+		}
+		entry, ok := entries[name]
+		if !ok {
+			return nil, false, nil
+		}
+		hash = entry.hash
+	} else {
+		tree, err := r.tree(rev)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read %s:%s: %w", rev, name, err)
+		}
+		if tree == nil {
+			return nil, false, nil
+		}
+		entry, err := tree.FindEntry(name)
+		if err != nil {
+			return nil, false, nil
+		}
+		hash = entry.Hash
+	}
+
+	content, err := r.ReadBlob(hash.String())
+	if err != nil {
+		return nil, false, err
+	}
+	return content, true, nil
+}
+
+// ListFiles returns the paths of the files directly inside dir, relative to the
+// repository root, at rev or in the index when rev is empty.
+func (r *goGitRepository) ListFiles(rev, dir string) ([]string, error) {
+	var entries map[string]indexEntry
+	if rev == "" {
+		var err error
+		if entries, err = r.indexEntries(); err != nil {
+			return nil, fmt.Errorf("failed to list files in %s: %w", dir, err)
+		}
+	} else {
+		tree, err := r.tree(rev)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files in %s: %w", dir, err)
+		}
+		if tree == nil {
+			return nil, nil
+		}
+		if entries, err = treeEntries(tree); err != nil {
+			return nil, fmt.Errorf("failed to list files in %s: %w", dir, err)
+		}
+	}
+
+	var files []string
+	for name := range entries {
+		if path.Dir(name) == path.Clean(dir) {
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ReadBlob returns the content of the blob with the given object name.
+func (r *goGitRepository) ReadBlob(hash string) ([]byte, error) {
+	blob, err := r.repo.BlobObject(plumbing.NewHash(hash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object %s: %w", hash, err)
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object %s: %w", hash, err)
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// BlobSize returns the size in bytes of the blob with the given object name.
+func (r *goGitRepository) BlobSize(hash string) (int64, error) {
+	blob, err := r.repo.BlobObject(plumbing.NewHash(hash))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the size of %s: %w", hash, err)
+	}
+	return blob.Size, nil
+}
+
+// toCommit converts a go-git commit.
+func toCommit(c *object.Commit) Commit {
+	return Commit{
+		Hash:    c.Hash.String(),
+		Message: strings.TrimSpace(c.Message),
+		Merge:   c.NumParents() > 1,
+	}
+}
+
+// log returns up to n commits reachable from rev, newest first, leaving out
+// those for which skip is true. A negative n means no limit.
+func (r *goGitRepository) log(rev string, n int, skip func(plumbing.Hash) bool) ([]*object.Commit, error) {
+	start, err := r.commit(rev)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := r.repo.Log(&gogit.LogOptions{From: start.Hash})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the history of %s: %w", rev, err)
+	}
+	defer iter.Close()
+
+	var commits []*object.Commit
+	for n < 0 || len(commits) < n {
+		commit, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the history of %s: %w", rev, err)
+		}
+		if skip == nil || !skip(commit.Hash) {
+			commits = append(commits, commit)
+		}
+	}
+	return commits, nil
+}
+
+// ListCommits returns the commits in revRange, oldest first. A range
+// "a..b" holds the commits reachable from b but not from a.
+func (r *goGitRepository) ListCommits(revRange string) ([]Commit, error) {
+	from, to, isRange := strings.Cut(revRange, "..")
+	if !isRange {
+		commit, err := r.commit(revRange)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list commits for %s: %w", revRange, err)
+		}
+		return []Commit{toCommit(commit)}, nil
+	}
+	if from == "" {
+		from = "HEAD"
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+
+	excluded, err := r.log(from, -1, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits for %s: %w", revRange, err)
+	}
+	seen := make(map[plumbing.Hash]bool, len(excluded))
+	for _, commit := range excluded {
+		seen[commit.Hash] = true
+	}
+	included, err := r.log(to, -1, func(hash plumbing.Hash) bool { return seen[hash] })
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits for %s: %w", revRange, err)
+	}
+
+	commits := make([]Commit, len(included))
+	for i, commit := range included {
+		commits[len(included)-1-i] = toCommit(commit)
+	}
+	return commits, nil
+}
+
+// RecentCommits returns up to n commits reachable from HEAD, newest first.
+func (r *goGitRepository) RecentCommits(n int) ([]Commit, error) {
+	if _, err := r.RevParse("HEAD"); err != nil {
+		return nil, nil
+	}
+
+	found, err := r.log("HEAD", n, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recent commits: %w", err)
+	}
+
+	commits := make([]Commit, len(found))
+	for i, commit := range found {
+		commits[i] = toCommit(commit)
+	}
+	return commits, nil
+}
+
+// History returns up to n commits reachable from HEAD with the paths each one
+// touched, newest first. Like git log, merges list no paths.
+func (r *goGitRepository) History(n int) ([]Commit, error) {
+	if _, err := r.RevParse("HEAD"); err != nil {
+		return nil, nil
+	}
+
+	found, err := r.log("HEAD", n, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit history: %w", err)
+	}
+
+	commits := make([]Commit, len(found))
+	for i, commit := range found {
+		commits[i] = toCommit(commit)
+		if commit.NumParents() > 1 {
+			continue
+		}
+		changes, err := r.changes(commit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read commit history: %w", err)
+		}
+		for _, change := range changes {
+			name := change.To.Name
+			if name == "" {
+				name = change.From.Name
+			}
+			commits[i].Files = append(commits[i].Files, name)
+		}
+	}
+	return commits, nil
+}
+
+// changes compares a commit with its first parent, or with an empty tree for a root commit.
+func (r *goGitRepository) changes(commit *object.Commit) (object.Changes, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var parentTree *object.Tree
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+	return object.DiffTree(parentTree, tree)
+}
+
+// renderChanges formats changes as a stat followed by the patch, with
+// abbreviated object names.
+func renderChanges(changes object.Changes) (string, error) {
+	patch, err := changes.Patch()
+	if err != nil {
+		return "", err
+	}
+	return patch.Stats().String() + "\n" + fullIndex.ReplaceAllString(patch.String(), "index $1..$2"), nil
+}
+
+// CommitDiff returns the stat and patch of the changes introduced by rev.
+func (r *goGitRepository) CommitDiff(rev string) (string, error) {
+	commit, err := r.commit(rev)
+	if err != nil {
+		return "", err
+	}
+	changes, err := r.changes(commit)
+	if err != nil {
+		return "", err
+	}
+	return renderChanges(changes)
+}
+
+// BranchDiff returns the stat and patch between the merge base of base and HEAD.
+func (r *goGitRepository) BranchDiff(base string) (string, error) {
+	head, err := r.commit("HEAD")
+	if err != nil {
+		return "", err
+	}
+	other, err := r.commit(base)
+	if err != nil {
+		return "", err
+	}
+	bases, err := head.MergeBase(other)
+	if err != nil {
+		return "", err
+	}
+	if len(bases) == 0 {
+		return "", fmt.Errorf("%s and HEAD have no common ancestor", base)
+	}
+
+	from, err := bases[0].Tree()
+	if err != nil {
+		return "", err
+	}
+	to, err := head.Tree()
+	if err != nil {
+		return "", err
+	}
+	changes, err := object.DiffTree(from, to)
+	if err != nil {
+		return "", err
+	}
+	if len(changes) == 0 {
+		return "", nil
+	}
+	return renderChanges(changes)
+}
+
+// CommitStaged commits the index with the committer from the git
+// configuration. Hooks never run, so NoVerify changes nothing; signing and
+// passed-through flags need the git binary.
+func (r *goGitRepository) CommitStaged(message string, opts CommitOptions) error {
+	switch {
+	case opts.Sign:
+		return fmt.Errorf("signing commits needs the exec git backend")
+	case len(opts.Args) > 0:
+		return fmt.Errorf("passing flags to git commit needs the exec git backend")
+	}
+
+	committer, err := r.configSignature()
+	if err != nil {
+		return err
+	}
+	author := committer
+	if opts.Amend {
+		// Like git, amending keeps the original author
+		head, err := r.commit("HEAD")
+		if err != nil {
+			return fmt.Errorf("there is no commit to amend")
+		}
+		author = &head.Author
+	}
+	if opts.Author != "" {
+		if author, err = parseSignature(opts.Author); err != nil {
+			return err
+		}
+	}
+
+	if !strings.HasSuffix(message, "\n") {
+		message += "\n"
+	}
+	if opts.Signoff {
+		trailer := fmt.Sprintf("Signed-off-by: %s <%s>", committer.Name, committer.Email)
+		if !strings.Contains(message, trailer+"\n") {
+			message += "\n" + trailer + "\n"
+		}
+	}
+
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to open the working tree: %w", err)
+	}
+	hash, err := worktree.Commit(message, &gogit.CommitOptions{Author: author, Committer: committer, Amend: opts.Amend})
+	if err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+
+	branch, err := r.CurrentBranch()
+	if err != nil || branch == "" {
+		branch = "detached HEAD"
+	}
+	subject, _, _ := strings.Cut(message, "\n")
+	fmt.Fprintf(os.Stdout, "[%s %.7s] %s\n", branch, hash.String(), subject)
+	return nil
+}
+
+// configSignature returns the user from the git configuration, dated now.
+func (r *goGitRepository) configSignature() (*object.Signature, error) {
+	cfg, err := r.repo.ConfigScoped(config.SystemScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the git configuration: %w", err)
+	}
+	if cfg.User.Name == "" || cfg.User.Email == "" {
+		return nil, fmt.Errorf("user.name and user.email must be set in the git configuration")
+	}
+	return &object.Signature{Name: cfg.User.Name, Email: cfg.User.Email, When: time.Now()}, nil
+}
+
+// signaturePattern matches "Name <email>".
+var signaturePattern = regexp.MustCompile(`^\s*(.*?)\s*<([^<>]*)>\s*$`)
+
+// parseSignature reads an author given as "Name <email>", dated now.
+func parseSignature(value string) (*object.Signature, error) {
+	match := signaturePattern.FindStringSubmatch(value)
+	if match == nil || match[1] == "" {
+		return nil, fmt.Errorf("invalid author %q, expected \"Name <email>\"", value)
+	}
+	return &object.Signature{Name: match[1], Email: match[2], When: time.Now()}, nil
+}
+
+// CreateBranch creates a branch at HEAD and checks it out, keeping the index
+// and working tree. On an unborn branch HEAD is just pointed at the new name.
+func (r *goGitRepository) CreateBranch(name string) error {
+	ref := plumbing.NewBranchReferenceName(name)
+	if _, err := r.repo.Storer.Reference(ref); err == nil {
+		return fmt.Errorf("a branch named %q already exists", name)
+	}
+
+	if _, err := r.repo.Head(); errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return r.repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, ref))
+	}
+
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to open the working tree: %w", err)
+	}
+	if err := worktree.Checkout(&gogit.CheckoutOptions{Branch: ref, Create: true, Keep: true}); err != nil {
+		return fmt.Errorf("failed to create branch %s: %w", name, err)
+	}
+	fmt.Fprintf(os.Stdout, "Switched to a new branch '%s'\n", name)
+	return nil
+}
+
+// RewordCommits replaces the messages of the given commits, keyed by full
+// hash, by rewriting every commit from the oldest of them up to HEAD with the
+// same tree, authors and dates. Signatures of the rewritten commits are dropped.
+func (r *goGitRepository) RewordCommits(messages map[string]string) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	head, err := r.repo.Head()
+	if err != nil {
+		return fmt.Errorf("unknown revision HEAD")
+	}
+	commit, err := r.repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("unknown revision HEAD")
+	}
+
+	// Follow HEAD back until every commit to reword is found
+	var chain []*object.Commit
+	remaining := len(messages)
+	for {
+		if commit.NumParents() > 1 {
+			return fmt.Errorf("cannot reword across merge commits")
+		}
+		chain = append(chain, commit)
+		if _, ok := messages[commit.Hash.String()]; ok {
+			if remaining--; remaining == 0 {
+				break
+			}
+		}
+		if commit.NumParents() == 0 {
+			for hash := range messages {
+				if !containsCommit(chain, hash) {
+					return fmt.Errorf("commit %s is not an ancestor of HEAD", hash)
+				}
+			}
+		}
+		if commit, err = commit.Parent(0); err != nil {
+			return fmt.Errorf("failed to inspect history: %w", err)
+		}
+	}
+
+	var rewritten plumbing.Hash
+	for i := len(chain) - 1; i >= 0; i-- {
+		c := *chain[i]
+		if i < len(chain)-1 {
+			c.ParentHashes = []plumbing.Hash{rewritten}
+		}
+		if msg, ok := messages[c.Hash.String()]; ok {
+			c.Message = msg + "\n"
+		}
+		c.PGPSignature = ""
+
+		obj := r.repo.Storer.NewEncodedObject()
+		if err := c.Encode(obj); err != nil {
+			return fmt.Errorf("failed to rewrite commit %s: %w", c.Hash, err)
+		}
+		if rewritten, err = r.repo.Storer.SetEncodedObject(obj); err != nil {
+			return fmt.Errorf("failed to rewrite commit %s: %w", c.Hash, err)
+		}
+	}
+
+	name := plumbing.HEAD
+	if head.Name().IsBranch() {
+		name = head.Name()
+	}
+	return r.repo.Storer.SetReference(plumbing.NewHashReference(name, rewritten))
+}
+
+// containsCommit reports whether the chain holds the commit with the given hash.
+func containsCommit(chain []*object.Commit, hash string) bool {
+	for _, c := range chain {
+		if c.Hash.String() == hash {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,308 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// lines returns n numbered lines, one per line, starting with prefix.
+func lines(prefix string, n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		b.WriteString(prefix)
+		b.WriteString(strings.Repeat("x", i%7))
+		b.WriteString(" line ")
+		b.WriteString(string(rune('a' + i%26)))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// testWorktree changes the files of a repository and stages them.
+type testWorktree struct {
+	t        *testing.T
+	dir      string
+	worktree *gogit.Worktree
+}
+
+func (w testWorktree) write(name, content string, mode os.FileMode) {
+	w.t.Helper()
+	path := filepath.Join(w.dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		w.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		w.t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		w.t.Fatal(err)
+	}
+	w.add(name)
+}
+
+func (w testWorktree) symlink(name, target string) {
+	w.t.Helper()
+	path := filepath.Join(w.dir, name)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		w.t.Fatal(err)
+	}
+	if err := os.Symlink(target, path); err != nil {
+		w.t.Fatal(err)
+	}
+	w.add(name)
+}
+
+func (w testWorktree) add(name string) {
+	w.t.Helper()
+	if _, err := w.worktree.Add(name); err != nil {
+		w.t.Fatal(err)
+	}
+}
+
+func (w testWorktree) remove(name string) {
+	w.t.Helper()
+	if _, err := w.worktree.Remove(name); err != nil {
+		w.t.Fatal(err)
+	}
+}
+
+func (w testWorktree) move(from, to string) {
+	w.t.Helper()
+	if _, err := w.worktree.Move(from, to); err != nil {
+		w.t.Fatal(err)
+	}
+}
+
+func (w testWorktree) commit() {
+	w.t.Helper()
+	w.commitMessage("initial")
+}
+
+func (w testWorktree) commitMessage(message string) plumbing.Hash {
+	w.t.Helper()
+	signature := &object.Signature{Name: "Test", Email: "test@example.com", When: time.Unix(1700000000, 0)}
+	hash, err := w.worktree.Commit(message, &gogit.CommitOptions{Author: signature, Committer: signature})
+	if err != nil {
+		w.t.Fatal(err)
+	}
+	return hash
+}
+
+func TestDiffIndexBackendsAgree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	// Keep the user's git configuration out of the exec backend
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	binaryContent := "\x00\x01\x02binary\x00"
+	tests := []struct {
+		name     string
+		setup    func(w testWorktree) // committed as HEAD; nil leaves HEAD unborn
+		change   func(w testWorktree)
+		statuses string
+	}{
+		{
+			name:     "add before the first commit",
+			change:   func(w testWorktree) { w.write("a.txt", "hello\n", 0o644) },
+			statuses: "A",
+		},
+		{
+			name:     "add",
+			setup:    func(w testWorktree) { w.write("a.txt", "hello\n", 0o644) },
+			change:   func(w testWorktree) { w.write("b.txt", lines("b", 5), 0o644) },
+			statuses: "A",
+		},
+		{
+			name:     "modify",
+			setup:    func(w testWorktree) { w.write("a.txt", lines("a", 30), 0o644) },
+			change:   func(w testWorktree) { w.write("a.txt", strings.Replace(lines("a", 30), "line k", "line K", 1), 0o644) },
+			statuses: "M",
+		},
+		{
+			name: "delete",
+			setup: func(w testWorktree) {
+				w.write("a.txt", lines("a", 10), 0o644)
+				w.write("b.txt", "keep\n", 0o644)
+			},
+			change:   func(w testWorktree) { w.remove("a.txt") },
+			statuses: "D",
+		},
+		{
+			name:     "rename",
+			setup:    func(w testWorktree) { w.write("old/a.txt", lines("a", 100), 0o644) },
+			change:   func(w testWorktree) { w.move("old/a.txt", "new/a.txt") },
+			statuses: "R",
+		},
+		{
+			name:  "rename with edits",
+			setup: func(w testWorktree) { w.write("a.go", lines("a", 40), 0o644) },
+			change: func(w testWorktree) {
+				w.remove("a.go")
+				w.write("b.go", lines("a", 40)+"extra\n", 0o644)
+			},
+			statuses: "R",
+		},
+		{
+			name:  "copy from a modified file",
+			setup: func(w testWorktree) { w.write("a.txt", lines("a", 20), 0o644) },
+			change: func(w testWorktree) {
+				w.write("copy.txt", lines("a", 20), 0o644)
+				w.write("a.txt", lines("a", 20)+"more\n", 0o644)
+			},
+			statuses: "MC",
+		},
+		{
+			name:  "renamed twice",
+			setup: func(w testWorktree) { w.write("a.txt", lines("a", 20), 0o644) },
+			change: func(w testWorktree) {
+				w.write("b.txt", lines("a", 20), 0o644)
+				w.move("a.txt", "c.txt")
+			},
+			statuses: "CR",
+		},
+		{
+			name:  "binary",
+			setup: func(w testWorktree) { w.write("image.bin", binaryContent, 0o644) },
+			change: func(w testWorktree) {
+				w.write("image.bin", binaryContent+"more", 0o644)
+				w.write("new.bin", "\x00new", 0o644)
+			},
+			statuses: "MA",
+		},
+		{
+			name:     "mode",
+			setup:    func(w testWorktree) { w.write("run.sh", "#!/bin/sh\necho hi\n", 0o644) },
+			change:   func(w testWorktree) { w.write("run.sh", "#!/bin/sh\necho hi\n", 0o755) },
+			statuses: "M",
+		},
+		{
+			name:     "type change",
+			setup:    func(w testWorktree) { w.write("link", "plain file\n", 0o644) },
+			change:   func(w testWorktree) { w.symlink("link", "target") },
+			statuses: "T",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			repo, err := gogit.PlainInit(dir, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			worktree, err := repo.Worktree()
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := testWorktree{t: t, dir: dir, worktree: worktree}
+			if tt.setup != nil {
+				tt.setup(w)
+				w.commit()
+			}
+			tt.change(w)
+
+			want, err := NewExecRepository(dir).DiffIndex("HEAD")
+			if err != nil {
+				t.Fatalf("exec backend: %v", err)
+			}
+			got, err := NewGoGitRepository(repo).DiffIndex("HEAD")
+			if err != nil {
+				t.Fatalf("go-git backend: %v", err)
+			}
+
+			var statuses []byte
+			for _, file := range want.Files {
+				statuses = append(statuses, file.Status)
+			}
+			if string(statuses) != tt.statuses {
+				t.Errorf("exec statuses = %q, want %q", statuses, tt.statuses)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("go-git result differs from exec\ngot:  %+v\nwant: %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDiffIndexInMemory(t *testing.T) {
+	repo, err := gogit.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) {
+		t.Helper()
+		file, err := worktree.Filesystem.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		if err := file.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worktree.Add(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("a.txt", lines("a", 100))
+	w := testWorktree{t: t, worktree: worktree}
+	w.commit()
+	w.move("a.txt", "b.txt")
+
+	result, err := NewGoGitRepository(repo).DiffIndex("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 1 {
+		t.Fatalf("got %d files, want 1", len(result.Files))
+	}
+	file := result.Files[0]
+	if file.Status != 'R' || file.OldPath != "a.txt" || file.Path != "b.txt" || file.Similarity != 100 {
+		t.Errorf("got %c %s -> %s (%d%%), want R a.txt -> b.txt (100%%)", file.Status, file.OldPath, file.Path, file.Similarity)
+	}
+	if file.Additions != 0 || file.Deletions != 0 || len(file.Hunks) != 0 {
+		t.Errorf("an exact rename has no patch, got +%d -%d and %d hunks", file.Additions, file.Deletions, len(file.Hunks))
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name     string
+		src, dst string
+		want     int // percent
+	}{
+		{"identical", "a\nb\nc\n", "a\nb\nc\n", 100},
+		{"appended line", lines("", 30), lines("", 30) + "extra\n", 98},
+		{"CRLF counts as LF", "a\r\nb\r\n", "a\nb\n", 66},
+		{"too different in size", "a\n", strings.Repeat("a\n", 10), 0},
+		{"nothing shared", "a\nb\n", "c\nd\n", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, dst := []byte(tt.src), []byte(tt.dst)
+			score := similarity(len(src), hashSpans(src, true), len(dst), hashSpans(dst, true))
+			if got := score * 100 / maxScore; got != tt.want {
+				t.Errorf("similarity = %d%%, want %d%%", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MessageFile is the name of the file in the git directory that keeps the
+// last message combo tried to commit with.
+const MessageFile = "COMBO_MSG"
+
+// ErrNoSavedMessage is returned when no commit message has been saved.
+var ErrNoSavedMessage = errors.New("no saved commit message; run combo commit to generate one")
+
+// messagePath returns the path of MessageFile in the current repository.
+func messagePath() (string, error) {
+	dir, err := current.GitDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, MessageFile), nil
+}
+
+// SaveMessage keeps message in the git directory, so that a commit rejected
+// by a hook can be retried with it.
+func SaveMessage(message string) error {
+	path, err := messagePath()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(message+"\n"), 0o644); err != nil {
+		return fmt.Errorf("failed to save the commit message: %w", err)
+	}
+	return nil
+}
+
+// SavedMessage returns the message kept by SaveMessage, or ErrNoSavedMessage
+// if there is none.
+func SavedMessage() (string, error) {
+	path, err := messagePath()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNoSavedMessage
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the saved commit message: %w", err)
+	}
+
+	message := strings.TrimSpace(string(data))
+	if message == "" {
+		return "", ErrNoSavedMessage
+	}
+	return message, nil
+}
+
+// ClearMessage removes the message kept by SaveMessage once it has been committed.
+func ClearMessage() error {
+	path, err := messagePath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove the saved commit message: %w", err)
+	}
+	return nil
+}
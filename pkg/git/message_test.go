@@ -0,0 +1,86 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// useRepository makes r the current repository for the rest of the test.
+func useRepository(t *testing.T, r Repository) {
+	previous := current
+	Use(r)
+	t.Cleanup(func() { Use(previous) })
+}
+
+func TestSavedMessage(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	useRepository(t, NewGoGitRepository(repo))
+
+	if _, err := SavedMessage(); !errors.Is(err, ErrNoSavedMessage) {
+		t.Fatalf("SavedMessage() before saving: got %v, want ErrNoSavedMessage", err)
+	}
+
+	message := "feat: add login\n\nWith a body."
+	if err := SaveMessage(message); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git", MessageFile)); err != nil {
+		t.Errorf("message is not kept in the git directory: %v", err)
+	}
+	got, err := SavedMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != message {
+		t.Errorf("SavedMessage() = %q, want %q", got, message)
+	}
+
+	if err := ClearMessage(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SavedMessage(); !errors.Is(err, ErrNoSavedMessage) {
+		t.Errorf("SavedMessage() after clearing: got %v, want ErrNoSavedMessage", err)
+	}
+	// Clearing twice is fine
+	if err := ClearMessage(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSavedMessageBlank(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	useRepository(t, NewGoGitRepository(repo))
+
+	if err := os.WriteFile(filepath.Join(dir, ".git", MessageFile), []byte("\n  \n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SavedMessage(); !errors.Is(err, ErrNoSavedMessage) {
+		t.Errorf("SavedMessage() of a blank file: got %v, want ErrNoSavedMessage", err)
+	}
+}
+
+func TestSavedMessageInMemory(t *testing.T) {
+	repo, err := gogit.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	useRepository(t, NewGoGitRepository(repo))
+
+	if err := SaveMessage("fix: typo"); err == nil {
+		t.Error("SaveMessage() succeeded without a git directory")
+	}
+}
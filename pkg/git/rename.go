@@ -0,0 +1,210 @@
+package git
+
+import (
+	"path"
+	"sort"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+)
+
+// Scores of rename detection, on the scale git uses: maxScore is an identical
+// file and minScore the 50% similarity git diff -M requires by default.
+const (
+	maxScore = 60000
+	minScore = maxScore / 2
+)
+
+// spanHashBase is the modulus of the hashes of the spans compared by similarity.
+const spanHashBase = 107927
+
+// renameSource is a version of a file that added files may be renamed or
+// copied from: the old side of a deletion, or of a modification, which can
+// only be a copy source because the file stays.
+type renameSource struct {
+	path    string
+	entry   indexEntry
+	deleted bool
+	used    int // times paired with a destination
+}
+
+// renameDestination is an added file and the source it was paired with, if any.
+type renameDestination struct {
+	path   string
+	entry  indexEntry
+	source int // index into the sources, or -1
+	score  int
+}
+
+// renameCandidate is a possible pairing of a destination with a source.
+type renameCandidate struct {
+	destination, source int
+	score               int
+	sameName            bool
+}
+
+// detectRenames pairs added files with the deleted or modified files they
+// were renamed or copied from, the way git diff -M -C does: identical
+// contents first, preferring unused sources with the same file name, then
+// files of at least minScore similarity, best first. A deleted source may be
+// renamed only once; its further pairings, like all pairings with a modified
+// source, are copies. read returns the content of an entry and whether it is
+// binary.
+func detectRenames(sources []*renameSource, destinations []*renameDestination, read func(indexEntry) ([]byte, bool, error)) error {
+	for _, destination := range destinations {
+		destination.source = -1
+	}
+
+	// Identical contents
+	for _, destination := range destinations {
+		best, bestScore := -1, -1
+		for i, source := range sources {
+			if source.entry.hash != destination.entry.hash {
+				continue
+			}
+			// Other than regular files only pair with the same mode
+			if (!isRegular(source.entry.mode) || !isRegular(destination.entry.mode)) && source.entry.mode != destination.entry.mode {
+				continue
+			}
+			score := 0
+			if source.used == 0 && source.deleted {
+				score++
+			}
+			if path.Base(source.path) == path.Base(destination.path) {
+				score++
+			}
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best >= 0 {
+			destination.source, destination.score = best, maxScore
+			sources[best].used++
+		}
+	}
+
+	// Similar contents of regular files
+	type content struct {
+		size  int
+		spans map[uint32]int
+	}
+	contents := make(map[indexEntry]*content)
+	load := func(entry indexEntry) (*content, error) {
+		if c, ok := contents[entry]; ok {
+			return c, nil
+		}
+		data, isBinary, err := read(entry)
+		if err != nil {
+			return nil, err
+		}
+		c := &content{size: len(data), spans: hashSpans(data, !isBinary)}
+		contents[entry] = c
+		return c, nil
+	}
+
+	var candidates []renameCandidate
+	for d, destination := range destinations {
+		if destination.source >= 0 || !isRegular(destination.entry.mode) {
+			continue
+		}
+		dst, err := load(destination.entry)
+		if err != nil {
+			return err
+		}
+		for s, source := range sources {
+			if !isRegular(source.entry.mode) {
+				continue
+			}
+			src, err := load(source.entry)
+			if err != nil {
+				return err
+			}
+			score := similarity(src.size, src.spans, dst.size, dst.spans)
+			if score >= minScore {
+				candidates = append(candidates, renameCandidate{
+					destination: d,
+					source:      s,
+					score:       score,
+					sameName:    path.Base(source.path) == path.Base(destination.path),
+				})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].sameName && !candidates[j].sameName
+	})
+
+	// Renames take unused deleted sources, then copies any source
+	for _, copies := range []bool{false, true} {
+		for _, candidate := range candidates {
+			destination, source := destinations[candidate.destination], sources[candidate.source]
+			if destination.source >= 0 {
+				continue
+			}
+			if !copies && (source.used > 0 || !source.deleted) {
+				continue
+			}
+			destination.source, destination.score = candidate.source, candidate.score
+			source.used++
+		}
+	}
+	return nil
+}
+
+// hashSpans counts the bytes of the content by the hash of the span they
+// belong to. A span ends at a newline or after 64 bytes; in text, the
+// carriage return of a CRLF does not count.
+func hashSpans(data []byte, text bool) map[uint32]int {
+	spans := make(map[uint32]int)
+	var accum1, accum2 uint32
+	n := 0
+	for i := 0; i < len(data); i++ {
+		c := uint32(data[i])
+		if text && c == '\r' && i+1 < len(data) && data[i+1] == '\n' {
+			continue
+		}
+		old := accum1
+		accum1 = (accum1 << 7) ^ (accum2 >> 25)
+		accum2 = (accum2 << 7) ^ (old >> 25)
+		accum1 += c
+		n++
+		if n < 64 && c != '\n' {
+			continue
+		}
+		spans[(accum1+accum2*0x61)%spanHashBase] += n
+		n, accum1, accum2 = 0, 0, 0
+	}
+	if n > 0 {
+		spans[(accum1+accum2*0x61)%spanHashBase] += n
+	}
+	return spans
+}
+
+// similarity scores how much of the larger of two contents the other one
+// shares, between 0 and maxScore. Contents whose sizes alone rule out
+// minScore are not compared.
+func similarity(srcSize int, src map[uint32]int, dstSize int, dst map[uint32]int) int {
+	largest, smallest := max(srcSize, dstSize), min(srcSize, dstSize)
+	if dstSize == 0 || largest*(maxScore-minScore) < (largest-smallest)*maxScore {
+		return 0
+	}
+
+	copied := 0
+	for hash, count := range src {
+		copied += min(count, dst[hash])
+	}
+	return int(int64(copied) * maxScore / int64(largest))
+}
+
+// isRegular reports whether mode is that of a regular file, executable or not.
+func isRegular(mode filemode.FileMode) bool {
+	return mode.IsRegular() || mode == filemode.Executable
+}
+
+// sameType reports whether two modes are of the same type of file, so that
+// a change between them is no type change: regular files, symlinks or submodules.
+func sameType(a, b filemode.FileMode) bool {
+	return a == b || isRegular(a) && isRegular(b)
+}
@@ -0,0 +1,267 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Repository is the access to a git repository used by combo. The package
+// level functions delegate to the repository selected with Use.
+type Repository interface {
+	// RepoRoot returns the top-level directory of the working tree.
+	RepoRoot() (string, error)
+	// CurrentBranch returns the short name of the checked out branch, or an
+	// empty string when HEAD is detached.
+	CurrentBranch() (string, error)
+	// RevParse resolves a revision to its full commit hash.
+	RevParse(rev string) (string, error)
+	// GitDir returns the absolute path of the git directory, which is private
+	// to the worktree when run inside a linked one.
+	GitDir() (string, error)
+
+	// StagedFiles returns the paths of the staged files.
+	StagedFiles() ([]string, error)
+	// UnstagedFiles returns the paths of the tracked files whose working tree
+	// content differs from the index.
+	UnstagedFiles() ([]string, error)
+	// Stage adds the working tree content of the given files to the index.
+	Stage(paths ...string) error
+	// DiffIndex returns the differences between base and the index, or nil if
+	// there are none. A base that does not exist, such as HEAD before the first
+	// commit, is compared as an empty tree.
+	DiffIndex(base string, pathspecs ...string) (*DiffResult, error)
+	// ShowFile returns the content of path at rev, or in the index when rev
+	// is empty. ok is false if the file does not exist there.
+	ShowFile(rev, path string) (content []byte, ok bool, err error)
+	// ListFiles returns the files directly inside dir, relative to the
+	// repository root, at rev or in the index when rev is empty.
+	ListFiles(rev, dir string) ([]string, error)
+	// ReadBlob returns the content of the blob with the given object name.
+	ReadBlob(hash string) ([]byte, error)
+	// BlobSize returns the size in bytes of the blob with the given object name.
+	BlobSize(hash string) (int64, error)
+
+	// ListCommits returns the commits in revRange, oldest first.
+	ListCommits(revRange string) ([]Commit, error)
+	// RecentCommits returns up to n commits reachable from HEAD, newest first.
+	RecentCommits(n int) ([]Commit, error)
+	// History returns up to n commits reachable from HEAD with their files, newest first.
+	History(n int) ([]Commit, error)
+	// CommitDiff returns the stat and patch of the changes introduced by rev.
+	CommitDiff(rev string) (string, error)
+	// BranchDiff returns the stat and patch between the merge base of base and HEAD.
+	BranchDiff(base string) (string, error)
+
+	// CommitStaged records the staged changes with the given message.
+	CommitStaged(message string, opts CommitOptions) error
+	// CreateBranch creates a branch at HEAD and checks it out, keeping the
+	// index and working tree.
+	CreateBranch(name string) error
+	// RewordCommits replaces the messages of the given commits, keyed by full hash.
+	RewordCommits(messages map[string]string) error
+}
+
+// CommitOptions adjust how CommitStaged records a commit.
+type CommitOptions struct {
+	Amend    bool     // replace HEAD instead of committing on top of it
+	Sign     bool     // GPG-sign the commit
+	SignKey  string   // key to sign with; empty uses the configured one
+	NoVerify bool     // skip the pre-commit and commit-msg hooks
+	Author   string   // author in the form "Name <email>"; empty keeps the default
+	Signoff  bool     // add a Signed-off-by trailer for the committer
+	Args     []string // further git commit flags, passed through as given
+}
+
+// Backend selects the implementation of Repository.
+type Backend string
+
+const (
+	ExecBackend  Backend = "exec"   // Runs the git binary.
+	GoGitBackend Backend = "go-git" // Reads and writes the repository in process, without a git binary.
+)
+
+func (b Backend) String() string {
+	return string(b)
+}
+
+// ParseBackend converts a configuration value into a Backend.
+func ParseBackend(value string) (Backend, error) {
+	switch Backend(value) {
+	case ExecBackend, GoGitBackend:
+		return Backend(value), nil
+	}
+	return "", fmt.Errorf("must be one of %s, %s, got %q", ExecBackend, GoGitBackend, value)
+}
+
+// workDir is the directory combo acts on, or "" for the process working directory.
+var workDir string
+
+// SetWorkDir makes combo act on the repository containing dir, as if it had
+// been started there. It switches the package level functions to the exec
+// backend in dir until a backend is opened with Open.
+func SetWorkDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("cannot change to %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("cannot change to %s: not a directory", dir)
+	}
+
+	workDir = dir
+	current = NewExecRepository(dir)
+	return nil
+}
+
+// Open returns the repository containing the working directory with the given
+// backend. Either way paths are relative to the root of the working tree, which
+// is the root of the linked worktree when run inside one.
+func Open(backend Backend) (Repository, error) {
+	dir := workDir
+	if dir == "" {
+		dir = "."
+	}
+
+	switch backend {
+	case ExecBackend:
+		// Running git from the root keeps paths root-relative even with diff.relative set
+		root, err := NewExecRepository(dir).RepoRoot()
+		if err != nil {
+			return nil, err
+		}
+		return NewExecRepository(root), nil
+	case GoGitBackend:
+		return OpenGoGitRepository(dir)
+	}
+	return nil, fmt.Errorf("unknown git backend %q", backend)
+}
+
+// current is the repository used by the package level functions.
+var current Repository = NewExecRepository("")
+
+// Use makes r the repository used by the package level functions.
+func Use(r Repository) {
+	current = r
+}
+
+// RepoRoot returns the top-level directory of the current repository.
+func RepoRoot() (string, error) {
+	return current.RepoRoot()
+}
+
+// CurrentBranch returns the short name of the checked out branch, or an empty string when HEAD is detached.
+func CurrentBranch() (string, error) {
+	return current.CurrentBranch()
+}
+
+// RevParse resolves a revision to its full commit hash.
+func RevParse(rev string) (string, error) {
+	return current.RevParse(rev)
+}
+
+// StagedFiles returns the paths of the staged files.
+func StagedFiles() ([]string, error) {
+	return current.StagedFiles()
+}
+
+// UnstagedFiles returns the paths of the tracked files changed in the working tree but not staged.
+func UnstagedFiles() ([]string, error) {
+	return current.UnstagedFiles()
+}
+
+// Stage adds the working tree content of the given files to the index.
+func Stage(paths ...string) error {
+	return current.Stage(paths...)
+}
+
+// FetchStagedDiff returns the staged changes, detecting renames and copies where
+// the backend can. Pathspecs, if any, limit the diff to the matching files.
+// It returns nil if nothing is staged.
+func FetchStagedDiff(pathspecs ...string) (*DiffResult, error) {
+	return current.DiffIndex("HEAD", pathspecs...)
+}
+
+// FetchAmendDiff returns the changes an amended HEAD would hold: those of
+// HEAD together with the staged ones, compared with HEAD's parent.
+func FetchAmendDiff() (*DiffResult, error) {
+	return current.DiffIndex("HEAD^")
+}
+
+// ShowFile returns the content of path at rev, or in the index when rev is
+// empty. ok is false if the file does not exist there.
+func ShowFile(rev, path string) (content []byte, ok bool, err error) {
+	return current.ShowFile(rev, path)
+}
+
+// ListFiles returns the paths of the files directly inside dir, relative to the
+// repository root, at rev or in the index when rev is empty. A revision that
+// does not exist yet, such as HEAD before the first commit, has no files.
+func ListFiles(rev, dir string) ([]string, error) {
+	return current.ListFiles(rev, dir)
+}
+
+// ListCommits returns the commits in revRange, oldest first.
+// A single revision (e.g. "HEAD~2") yields just that commit.
+func ListCommits(revRange string) ([]Commit, error) {
+	return current.ListCommits(revRange)
+}
+
+// RecentCommits returns up to n commits reachable from HEAD, newest first.
+// A repository without commits yields none.
+func RecentCommits(n int) ([]Commit, error) {
+	return current.RecentCommits(n)
+}
+
+// History returns up to n commits reachable from HEAD with the paths each one
+// touched, newest first. A repository without commits yields none.
+func History(n int) ([]Commit, error) {
+	return current.History(n)
+}
+
+// GetCommitDifferences retrieves the changes introduced by a commit, truncating if needed.
+func GetCommitDifferences(rev string) (string, error) {
+	diff, err := current.CommitDiff(rev)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve diff for %s: %w", rev, err)
+	}
+
+	if len(diff) > MaxDiffSize {
+		diff = diff[:MaxDiffSize] + "\n[...truncated]"
+	}
+
+	return diff, nil
+}
+
+// GetBranchDifferences retrieves the net changes between the merge base of base and HEAD, truncating if needed.
+func GetBranchDifferences(base string) (string, error) {
+	diff, err := current.BranchDiff(base)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve diff against %s: %w", base, err)
+	}
+
+	if strings.TrimSpace(diff) == "" {
+		return "", fmt.Errorf("no changes between %s and HEAD", base)
+	}
+
+	if len(diff) > MaxDiffSize {
+		diff = diff[:MaxDiffSize] + "\n[...truncated]"
+	}
+
+	return diff, nil
+}
+
+// CommitStaged records the staged changes with the given message.
+func CommitStaged(message string, opts CommitOptions) error {
+	return current.CommitStaged(message, opts)
+}
+
+// CreateBranch creates a branch at HEAD and checks it out.
+func CreateBranch(name string) error {
+	return current.CreateBranch(name)
+}
+
+// RewordCommits replaces the messages of the given commits, keyed by full hash.
+func RewordCommits(messages map[string]string) error {
+	return current.RewordCommits(messages)
+}
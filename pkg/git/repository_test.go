@@ -0,0 +1,59 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+)
+
+func TestBranchCommitsAndDiff(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	useRepository(t, NewGoGitRepository(repo))
+	w := testWorktree{t: t, dir: dir, worktree: worktree}
+
+	w.write("README.md", "readme\n", 0o644)
+	base := w.commitMessage("initial").String()
+
+	const n = 4
+	for i := 1; i <= n; i++ {
+		w.write(fmt.Sprintf("file%d.txt", i), lines(fmt.Sprintf("f%d", i), 100), 0o644)
+		w.commitMessage(fmt.Sprintf("feat: add file %d", i))
+	}
+
+	commits, err := ListCommits(base + "..HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != n {
+		t.Fatalf("ListCommits() returned %d commits, want %d", len(commits), n)
+	}
+	for i, c := range commits {
+		if want := fmt.Sprintf("feat: add file %d", i+1); c.Subject() != want {
+			t.Errorf("commit %d = %q, want %q", i, c.Subject(), want)
+		}
+	}
+
+	diff, err := GetBranchDifferences(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(diff, "\n[...truncated]") {
+		t.Errorf("diff of %d bytes is not marked as truncated", len(diff))
+	}
+	if want := MaxDiffSize + len("\n[...truncated]"); len(diff) != want {
+		t.Errorf("diff is %d bytes, want %d", len(diff), want)
+	}
+	if !strings.Contains(diff, "file1.txt") {
+		t.Errorf("diff does not start with the first file:\n%s", diff[:200])
+	}
+}
@@ -0,0 +1,76 @@
+package git
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Trailer is a "Key: value" line from the final paragraph of a commit message.
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+func (t Trailer) String() string {
+	return t.Key + ": " + t.Value
+}
+
+// trailerPattern matches a single git trailer line.
+var trailerPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*):\s+(.+)$`)
+
+// preservedTrailers lists the trailers carried over when commits are squashed,
+// keyed by lowercase name with their canonical spelling.
+var preservedTrailers = map[string]string{
+	"co-authored-by": "Co-authored-by",
+	"closes":         "Closes",
+	"fixes":          "Fixes",
+	"resolves":       "Resolves",
+	"refs":           "Refs",
+	"references":     "References",
+}
+
+// ParseTrailers returns the trailers found in the last paragraph of message.
+// The paragraph only counts as a trailer block if every line in it is a trailer.
+func ParseTrailers(message string) []Trailer {
+	paragraphs := strings.Split(strings.TrimSpace(message), "\n\n")
+	if len(paragraphs) < 2 {
+		return nil
+	}
+
+	var trailers []Trailer
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		match := trailerPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			return nil
+		}
+		trailers = append(trailers, Trailer{Key: match[1], Value: strings.TrimSpace(match[2])})
+	}
+
+	return trailers
+}
+
+// PreservedTrailers collects the co-author and issue reference trailers of the
+// given commits, in order of first appearance and without duplicates.
+func PreservedTrailers(commits []Commit) []Trailer {
+	seen := make(map[string]bool)
+
+	var trailers []Trailer
+	for _, c := range commits {
+		for _, t := range ParseTrailers(c.Message) {
+			key, ok := preservedTrailers[strings.ToLower(t.Key)]
+			if !ok {
+				continue
+			}
+			t.Key = key
+
+			id := strings.ToLower(t.String())
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			trailers = append(trailers, t)
+		}
+	}
+
+	return trailers
+}
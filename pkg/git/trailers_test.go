@@ -0,0 +1,62 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTrailers(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    []Trailer
+	}{
+		{
+			name:    "trailer block",
+			message: "feat: add login\n\nBody text.\n\nCo-authored-by: Ada <ada@example.com>\nCloses: #12\n",
+			want: []Trailer{
+				{Key: "Co-authored-by", Value: "Ada <ada@example.com>"},
+				{Key: "Closes", Value: "#12"},
+			},
+		},
+		{
+			name:    "subject only",
+			message: "Signed-off-by: Ada <ada@example.com>",
+		},
+		{
+			name:    "last paragraph is not all trailers",
+			message: "fix: typo\n\nRefs: #1\nand some prose",
+		},
+		{
+			name:    "value is trimmed",
+			message: "fix: typo\n\nRefs:   #7  ",
+			want:    []Trailer{{Key: "Refs", Value: "#7"}},
+		},
+		{
+			name:    "key must start with a letter",
+			message: "fix: typo\n\n1-key: value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseTrailers(tt.message); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTrailers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreservedTrailers(t *testing.T) {
+	commits := []Commit{
+		{Message: "feat: one\n\nco-authored-by: Ada <ada@example.com>\nSigned-off-by: Bob <bob@example.com>"},
+		{Message: "fix: two\n\nCo-Authored-By: Ada <ada@example.com>\nFIXES: #3"},
+		{Message: "chore: three"},
+	}
+	want := []Trailer{
+		{Key: "Co-authored-by", Value: "Ada <ada@example.com>"},
+		{Key: "Fixes", Value: "#3"},
+	}
+	if got := PreservedTrailers(commits); !reflect.DeepEqual(got, want) {
+		t.Errorf("PreservedTrailers() = %v, want %v", got, want)
+	}
+}
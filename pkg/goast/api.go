@@ -0,0 +1,285 @@
+package goast
+
+import (
+	"fmt"
+	"go/ast"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/tolgaOzen/combo/pkg/git"
+)
+
+// Incompatibility is a change to the exported API of a package that can break
+// code depending on it.
+type Incompatibility struct {
+	Package string // directory of the package, relative to the repository root
+	Message string // e.g. "removed func Retry"
+}
+
+func (i Incompatibility) String() string {
+	if i.Package == "." {
+		return i.Message
+	}
+	return i.Package + ": " + i.Message
+}
+
+// BreakingChanges compares the exported API of every package with staged Go
+// files between the base of the diff, usually HEAD, and the index, in the
+// spirit of apidiff. Only the syntax is compared, so a change is reported when
+// it can break callers:
+//
+//   - an exported function, method, type, variable or constant is removed
+//   - the signature of an exported function or method changes
+//   - the type of an exported variable or constant changes
+//   - an exported struct field is removed or its type changes
+//   - a method is added to, removed from or changed in an exported interface
+//   - the definition of any other exported type changes
+//
+// Test files, main packages and internal packages are not public API and are
+// skipped. Packages that fail to parse are skipped too.
+func BreakingChanges(staged *git.DiffResult) ([]Incompatibility, error) {
+	dirs := make(map[string]bool)
+	for _, change := range staged.Files {
+		// A file moved out of a package changes the package it left as well
+		for _, file := range []string{change.OldPath, change.Path} {
+			if path.Ext(file) == ".go" && !strings.HasSuffix(file, "_test.go") && public(path.Dir(file)) {
+				dirs[path.Dir(file)] = true
+			}
+		}
+	}
+
+	var result []Incompatibility
+	for _, dir := range sortedDirs(dirs) {
+		old, oldOK, err := loadPackage(staged.Base, dir)
+		if err != nil {
+			return nil, err
+		}
+		new, newOK, err := loadPackage("", dir)
+		if err != nil {
+			return nil, err
+		}
+		// A new package cannot break anyone, and a main package has no importers
+		if !oldOK || !newOK || old == nil || old.Package == "main" {
+			continue
+		}
+
+		for _, message := range compareAPI(old, new) {
+			result = append(result, Incompatibility{Package: dir, Message: message})
+		}
+	}
+	return result, nil
+}
+
+// public reports whether a package directory can be imported from other modules.
+func public(dir string) bool {
+	for _, part := range strings.Split(dir, "/") {
+		if part == "internal" || part == "testdata" || (strings.HasPrefix(part, ".") && part != ".") || strings.HasPrefix(part, "_") {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedDirs returns the keys of a set in order.
+func sortedDirs(set map[string]bool) []string {
+	dirs := make([]string, 0, len(set))
+	for dir := range set {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// loadPackage merges the exported declarations of the non-test Go files of a
+// directory at rev, or in the index when rev is empty. The package is nil if
+// the directory holds no Go files there; ok is false if one fails to parse.
+func loadPackage(rev, dir string) (pkg *File, ok bool, err error) {
+	files, err := git.ListFiles(rev, dir)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, file := range files {
+		if path.Ext(file) != ".go" || strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		src, err := load(rev, file)
+		if err != nil {
+			return nil, false, err
+		}
+		parsed, err := parse(file, src)
+		if err != nil {
+			return nil, false, nil
+		}
+		if parsed == nil {
+			continue
+		}
+
+		pkg = addExported(pkg, parsed)
+	}
+	return pkg, true, nil
+}
+
+// addExported adds the exported declarations of a file to the API of its
+// package, creating it if pkg is nil. Files for different platforms may
+// declare the same name, so declarations are keyed by name and build
+// constraint, and the order the files are read in does not matter.
+func addExported(pkg *File, file *File) *File {
+	if pkg == nil {
+		pkg = &File{Package: file.Package, Decls: make(map[string]Decl)}
+	}
+	for _, decl := range file.Decls {
+		if decl.Exported {
+			pkg.Decls[apiKey(decl.Name, decl.Build)] = decl
+		}
+	}
+	return pkg
+}
+
+// apiKey identifies a declaration in the API of a package.
+func apiKey(name, build string) string {
+	if build == "" {
+		return name
+	}
+	return name + " [" + build + "]"
+}
+
+// compareAPI lists the incompatible changes between two versions of the
+// exported declarations of a package. new may be nil if the package was removed.
+func compareAPI(old, new *File) []string {
+	var newDecls map[string]Decl
+	if new != nil {
+		newDecls = new.Decls
+	}
+
+	var messages []string
+	for _, key := range sortedDecls(old.Decls) {
+		o := old.Decls[key]
+		n, ok := lookupAPI(newDecls, o)
+
+		switch {
+		case !ok:
+			messages = append(messages, "removed "+describeAPI(o))
+		case o.Kind != n.Kind:
+			messages = append(messages, fmt.Sprintf("changed %s to %s", describeAPI(o), describeAPI(n)))
+		case o.Kind == "type":
+			messages = append(messages, compareType(o, n)...)
+		case o.Signature != n.Signature:
+			messages = append(messages, fmt.Sprintf("changed %s to %s", describeAPI(o), describeAPI(n)))
+		}
+	}
+	return messages
+}
+
+// lookupAPI finds the new version of an exported declaration.
+func lookupAPI(decls map[string]Decl, old Decl) (Decl, bool) {
+	names := []string{old.Name}
+	if old.Kind == "method" {
+		// Moving a method from the pointer to the value receiver keeps it
+		// in both method sets
+		names = append(names, strings.Replace(old.Name, "(*", "(", 1))
+	}
+	for _, name := range names {
+		if d, ok := decls[apiKey(name, old.Build)]; ok {
+			return d, true
+		}
+	}
+	// The declaration may have moved between a shared and a platform-specific
+	// file
+	for _, name := range names {
+		for _, key := range sortedDecls(decls) {
+			if d := decls[key]; d.Name == name && (d.Build == "" || old.Build == "") {
+				return d, true
+			}
+		}
+	}
+	return Decl{}, false
+}
+
+// describeAPI shows a declaration with the build constraint of its file.
+func describeAPI(d Decl) string {
+	if d.Build == "" {
+		return d.String()
+	}
+	return d.String() + " [" + d.Build + "]"
+}
+
+// compareType lists the incompatible changes to an exported type.
+func compareType(old, new Decl) []string {
+	if old.Body == new.Body {
+		return nil
+	}
+	if old.Signature != new.Signature || old.Members == nil || new.Members == nil {
+		return []string{"changed definition of " + describeAPI(new)}
+	}
+
+	interfaceType := strings.HasSuffix(new.Signature, " interface")
+	// Interfaces with unexported methods cannot be implemented elsewhere
+	sealed := false
+	var messages []string
+	for _, member := range sortedKeys(old.Members) {
+		typ, ok := new.Members[member]
+		if !exportedMember(member) {
+			sealed = true
+			if !interfaceType {
+				continue
+			}
+		}
+		switch {
+		case !ok:
+			messages = append(messages, fmt.Sprintf("removed %s %s.%s", memberKind(interfaceType), old.Name, member))
+		case typ != old.Members[member]:
+			messages = append(messages, fmt.Sprintf("changed type of %s %s.%s from %s to %s", memberKind(interfaceType), old.Name, member, old.Members[member], typ))
+		}
+	}
+	if interfaceType && !sealed {
+		// Every implementation outside the package lacks the new methods
+		for _, member := range sortedKeys(new.Members) {
+			if _, ok := old.Members[member]; !ok {
+				messages = append(messages, fmt.Sprintf("added method %s.%s to interface", new.Name, member))
+			}
+		}
+	}
+	return messages
+}
+
+// exportedMember reports whether a struct field, or the type of an embedded
+// field such as *pkg.Name, is exported.
+func exportedMember(name string) bool {
+	name = strings.TrimLeft(name, "*")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, "["); i >= 0 {
+		name = name[:i]
+	}
+	return ast.IsExported(name)
+}
+
+// memberKind names the members of a struct or interface type.
+func memberKind(interfaceType bool) string {
+	if interfaceType {
+		return "method"
+	}
+	return "field"
+}
+
+// sortedDecls returns the names of the declarations in order.
+func sortedDecls(decls map[string]Decl) []string {
+	names := make([]string, 0, len(decls))
+	for name := range decls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Footer describes incompatible changes as a BREAKING CHANGE footer.
+func Footer(changes []Incompatibility) string {
+	descriptions := make([]string, len(changes))
+	for i, change := range changes {
+		descriptions[i] = change.String()
+	}
+	return "BREAKING CHANGE: " + strings.Join(descriptions, "; ")
+}
@@ -0,0 +1,199 @@
+package goast
+
+import (
+	"reflect"
+	"testing"
+)
+
+// parseAPI parses the files of one version of a package, in the given order,
+// into its exported API.
+func parseAPI(t *testing.T, files ...[2]string) *File {
+	t.Helper()
+	var pkg *File
+	for _, file := range files {
+		parsed, err := Parse(file[0], []byte(file[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg = addExported(pkg, parsed)
+	}
+	return pkg
+}
+
+func TestCompareAPI(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want []string
+	}{
+		{
+			name: "unchanged",
+			old:  "func Retry(n int) error { return nil }",
+			new:  "func Retry(n int) error { return errNope }",
+		},
+		{
+			name: "removed func",
+			old:  "func Retry(n int) error { return nil }\nfunc retry() {}",
+			new:  "",
+			want: []string{"removed func Retry(n int) error"},
+		},
+		{
+			name: "removed method",
+			old:  "type Client struct{}\nfunc (c *Client) Do() error { return nil }",
+			new:  "type Client struct{}",
+			want: []string{"removed method (*Client).Do() error"},
+		},
+		{
+			name: "changed signature",
+			old:  "func Retry(n int) error { return nil }",
+			new:  "func Retry(n int, d time.Duration) error { return nil }",
+			want: []string{"changed func Retry(n int) error to func Retry(n int, d time.Duration) error"},
+		},
+		{
+			name: "pointer to value receiver",
+			old:  "type Client struct{}\nfunc (c *Client) Do() error { return nil }",
+			new:  "type Client struct{}\nfunc (c Client) Do() error { return nil }",
+		},
+		{
+			name: "value to pointer receiver",
+			old:  "type Client struct{}\nfunc (c Client) Do() error { return nil }",
+			new:  "type Client struct{}\nfunc (c *Client) Do() error { return nil }",
+			want: []string{"removed method (Client).Do() error"},
+		},
+		{
+			name: "removed and changed struct fields",
+			old:  "type Config struct {\n\tName string\n\tSize int\n\tTags []string\n\tsecret string\n}",
+			new:  "type Config struct {\n\tName string\n\tSize int64\n\tAdded bool\n}",
+			want: []string{
+				"changed type of field Config.Size from int to int64",
+				"removed field Config.Tags",
+			},
+		},
+		{
+			name: "method added to an interface",
+			old:  "type Store interface {\n\tGet(key string) string\n}",
+			new:  "type Store interface {\n\tGet(key string) string\n\tSet(key, value string)\n}",
+			want: []string{"added method Store.Set to interface"},
+		},
+		{
+			name: "method added to a sealed interface",
+			old:  "type Store interface {\n\tGet(key string) string\n\tsealed()\n}",
+			new:  "type Store interface {\n\tGet(key string) string\n\tSet(key, value string)\n\tsealed()\n}",
+		},
+		{
+			name: "method changed in an interface",
+			old:  "type Store interface {\n\tGet(key string) string\n}",
+			new:  "type Store interface {\n\tGet(key string) (string, bool)\n}",
+			want: []string{"changed type of method Store.Get from func(key string) string to func(key string) (string, bool)"},
+		},
+		{
+			name: "type kind changed",
+			old:  "type ID struct{ Value string }",
+			new:  "type ID string",
+			want: []string{"changed definition of type ID"},
+		},
+		{
+			name: "func became a var",
+			old:  "func Now() int { return 0 }",
+			new:  "var Now func() int",
+			want: []string{"changed func Now() int to var Now func() int"},
+		},
+		{
+			name: "changed variable type",
+			old:  "var Timeout int = 3",
+			new:  "var Timeout time.Duration = 3",
+			want: []string{"changed var Timeout int to var Timeout time.Duration"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := parseAPI(t, [2]string{"a.go", "package a\n" + tt.old})
+			new := parseAPI(t, [2]string{"a.go", "package a\n" + tt.new})
+			if got := compareAPI(old, new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compareAPI() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareAPIRemovedPackage(t *testing.T) {
+	old := parseAPI(t, [2]string{"a.go", "package a\nfunc Retry() {}\nconst Max = 3"})
+	want := []string{"removed const Max", "removed func Retry()"}
+	if got := compareAPI(old, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("compareAPI() = %q, want %q", got, want)
+	}
+}
+
+func TestCompareAPIBuildConstraints(t *testing.T) {
+	linux := [2]string{"open_linux.go", "package a\nfunc Open(path string) (int, error) { return 0, nil }"}
+	windows := [2]string{"open_windows.go", "package a\nfunc Open(path string) (uintptr, error) { return 0, nil }"}
+	tagged := [2]string{"open_other.go", "//go:build !linux && !windows\n\npackage a\nfunc Open(path string) (int, error) { return 0, nil }"}
+
+	tests := []struct {
+		name     string
+		old, new [][2]string
+		want     []string
+	}{
+		{
+			name: "unchanged in either order",
+			old:  [][2]string{linux, windows, tagged},
+			new:  [][2]string{tagged, windows, linux},
+		},
+		{
+			name: "changed on one platform",
+			old:  [][2]string{linux, windows},
+			new: [][2]string{
+				{"open_windows.go", windows[1]},
+				{"open_linux.go", "package a\nfunc Open(path string, flags int) (int, error) { return 0, nil }"},
+			},
+			want: []string{"changed func Open(path string) (int, error) [linux] to func Open(path string, flags int) (int, error) [linux]"},
+		},
+		{
+			name: "removed on one platform",
+			old:  [][2]string{windows, linux},
+			new:  [][2]string{linux},
+			want: []string{"removed func Open(path string) (uintptr, error) [windows]"},
+		},
+		{
+			name: "moved into a shared file",
+			old:  [][2]string{linux},
+			new:  [][2]string{{"open.go", linux[1]}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := compareAPI(parseAPI(t, tt.old...), parseAPI(t, tt.new...))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compareAPI() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildConstraint(t *testing.T) {
+	tests := []struct {
+		filename string
+		src      string
+		want     string
+	}{
+		{filename: "open.go", want: ""},
+		{filename: "linux.go", want: ""},
+		{filename: "open_linux.go", want: "linux"},
+		{filename: "open_windows_amd64.go", want: "windows && amd64"},
+		{filename: "open_arm64.go", want: "arm64"},
+		{filename: "open_unix.go", want: ""},
+		{filename: "open.go", src: "//go:build linux || darwin\n\n", want: "linux || darwin"},
+		{filename: "open_linux.go", src: "//go:build cgo || race\n\n", want: "linux && (cgo || race)"},
+		{filename: "open.go", src: "// Package a does things.\n", want: ""},
+	}
+	for _, tt := range tests {
+		file, err := Parse(tt.filename, []byte(tt.src+"package a\nfunc Open() {}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := file.Decls["Open"].Build; got != tt.want {
+			t.Errorf("build constraint of %s %q = %q, want %q", tt.filename, tt.src, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package goast
+
+import (
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"path"
+	"strings"
+)
+
+// knownOS and knownArch are the GOOS and GOARCH values a file name suffix
+// such as _linux.go or _windows_amd64.go restricts a file to.
+var (
+	knownOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+		"hurd": true, "illumos": true, "ios": true, "js": true, "linux": true, "nacl": true,
+		"netbsd": true, "openbsd": true, "plan9": true, "solaris": true, "wasip1": true,
+		"windows": true, "zos": true,
+	}
+	knownArch = map[string]bool{
+		"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true,
+		"arm64be": true, "loong64": true, "mips": true, "mipsle": true, "mips64": true,
+		"mips64le": true, "mips64p32": true, "mips64p32le": true, "ppc": true, "ppc64": true,
+		"ppc64le": true, "riscv": true, "riscv64": true, "s390": true, "s390x": true,
+		"sparc": true, "sparc64": true, "wasm": true,
+	}
+)
+
+// buildConstraint returns the build constraint of a file, from its name and
+// its //go:build line, e.g. "linux" or "windows && amd64". It is empty when
+// the file is built everywhere.
+func buildConstraint(filename string, src []byte) string {
+	var parts []string
+	if tags := fileNameTags(filename); tags != "" {
+		parts = append(parts, tags)
+	}
+
+	// Build constraints must appear before the package clause
+	f, err := parser.ParseFile(token.NewFileSet(), filename, src, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return strings.Join(parts, " && ")
+	}
+	for _, group := range f.Comments {
+		if group.Pos() >= f.Package {
+			break
+		}
+		for _, comment := range group.List {
+			if !constraint.IsGoBuild(comment.Text) {
+				continue
+			}
+			if expr, err := constraint.Parse(comment.Text); err == nil {
+				if len(parts) > 0 {
+					parts = append(parts, "("+expr.String()+")")
+				} else {
+					parts = append(parts, expr.String())
+				}
+			}
+		}
+	}
+	return strings.Join(parts, " && ")
+}
+
+// fileNameTags returns the GOOS and GOARCH a file name such as
+// file_linux_amd64.go restricts the file to, joined with &&.
+func fileNameTags(filename string) string {
+	name := strings.TrimSuffix(path.Base(filename), ".go")
+	name = strings.TrimSuffix(name, "_test")
+	// The first element is the name itself, so linux.go is built everywhere
+	i := strings.Index(name, "_")
+	if i < 0 {
+		return ""
+	}
+	elems := strings.Split(name[i+1:], "_")
+
+	n := len(elems)
+	switch {
+	case n >= 2 && knownOS[elems[n-2]] && knownArch[elems[n-1]]:
+		return elems[n-2] + " && " + elems[n-1]
+	case knownOS[elems[n-1]], knownArch[elems[n-1]]:
+		return elems[n-1]
+	}
+	return ""
+}
@@ -0,0 +1,435 @@
+package goast
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/tolgaOzen/combo/pkg/git"
+)
+
+// Mode defines how the declaration summary of Go files is used in the prompt.
+type Mode string
+
+const (
+	Off     Mode = "off"     // Only the raw patch is sent.
+	Append  Mode = "append"  // The summary is sent before the raw patch.
+	Replace Mode = "replace" // The summary replaces the patch of Go files.
+)
+
+func (m Mode) String() string {
+	return string(m)
+}
+
+// ParseMode converts a configuration value into a Mode.
+func ParseMode(value string) (Mode, error) {
+	switch Mode(value) {
+	case Off, Append, Replace:
+		return Mode(value), nil
+	}
+	return "", fmt.Errorf("must be one of %s, %s, %s, got %q", Off, Append, Replace, value)
+}
+
+// maxSummarySize caps the summary, like MaxDiffSize caps the patch.
+const maxSummarySize = 4000
+
+// Decl is a top-level declaration of a Go file.
+type Decl struct {
+	Kind      string // func, method, type, var or const
+	Name      string // e.g. Retry, (*Client).Do
+	Signature string // the declaration without its body, on a single line
+	Body      string // function body or value, to notice implementation changes
+	Exported  bool
+	// Build is the build constraint of the file declaring it, e.g. "linux";
+	// empty when the file is built everywhere.
+	Build string
+	// Members maps the fields of a struct or the methods of an interface to
+	// their types; nil for other declarations.
+	Members map[string]string
+}
+
+// String returns the declaration as it is shown in the summary.
+func (d Decl) String() string {
+	if d.Kind == "method" {
+		short := d.Name[strings.LastIndex(d.Name, ".")+1:]
+		return "method " + d.Name + strings.TrimPrefix(d.Signature, "func "+short)
+	}
+	return d.Signature
+}
+
+// File holds the declarations of one version of a Go file.
+type File struct {
+	Package string
+	Decls   map[string]Decl
+}
+
+// Parse reads the top-level declarations of a Go source file. Unexported
+// variables and constants are left out, as they rarely matter to a reader.
+func Parse(filename string, src []byte) (*File, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	file := &File{Package: f.Name.Name, Decls: make(map[string]Decl)}
+	build := buildConstraint(filename, src)
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			d := Decl{Kind: "func", Name: decl.Name.Name, Exported: decl.Name.IsExported(), Build: build}
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				recv := nodeString(fset, decl.Recv.List[0].Type)
+				d.Kind, d.Name = "method", "("+recv+")."+decl.Name.Name
+				d.Exported = d.Exported && receiverName(decl.Recv.List[0].Type).IsExported()
+			}
+			d.Signature = "func " + decl.Name.Name + strings.TrimPrefix(nodeString(fset, decl.Type), "func")
+			if decl.Body != nil {
+				d.Body = nodeString(fset, decl.Body)
+			}
+			file.Decls[d.Name] = d
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					d := Decl{
+						Kind:      "type",
+						Name:      spec.Name.Name,
+						Signature: "type " + spec.Name.Name + typeKind(spec),
+						Body:      nodeString(fset, spec),
+						Exported:  spec.Name.IsExported(),
+						Build:     build,
+						Members:   members(fset, spec.Type),
+					}
+					file.Decls[d.Name] = d
+				case *ast.ValueSpec:
+					for i, name := range spec.Names {
+						if !name.IsExported() {
+							continue
+						}
+						d := Decl{Kind: decl.Tok.String(), Name: name.Name, Exported: true, Build: build}
+						d.Signature = d.Kind + " " + name.Name
+						if spec.Type != nil {
+							d.Signature += " " + nodeString(fset, spec.Type)
+						}
+						if i < len(spec.Values) {
+							d.Body = nodeString(fset, spec.Values[i])
+						}
+						file.Decls[d.Name] = d
+					}
+				}
+			}
+		}
+	}
+	return file, nil
+}
+
+// nodeString prints a syntax node on a single line, without comments.
+func nodeString(fset *token.FileSet, node ast.Node) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, node); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// receiverName returns the type name of a method receiver such as *T or T[K].
+func receiverName(expr ast.Expr) *ast.Ident {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e
+		default:
+			return ast.NewIdent("_")
+		}
+	}
+}
+
+// typeKind describes what a type declaration defines, e.g. " struct" or " (alias)".
+func typeKind(spec *ast.TypeSpec) string {
+	if spec.Assign.IsValid() {
+		return " (alias)"
+	}
+	switch spec.Type.(type) {
+	case *ast.StructType:
+		return " struct"
+	case *ast.InterfaceType:
+		return " interface"
+	case *ast.FuncType:
+		return " func"
+	}
+	return ""
+}
+
+// members lists the fields of a struct or the methods of an interface.
+// Embedded fields are named after their type.
+func members(fset *token.FileSet, expr ast.Expr) map[string]string {
+	var fields *ast.FieldList
+	switch t := expr.(type) {
+	case *ast.StructType:
+		fields = t.Fields
+	case *ast.InterfaceType:
+		fields = t.Methods
+	default:
+		return nil
+	}
+
+	result := make(map[string]string)
+	for _, field := range fields.List {
+		typ := nodeString(fset, field.Type)
+		if len(field.Names) == 0 {
+			result[typ] = typ
+			continue
+		}
+		for _, name := range field.Names {
+			result[name.Name] = typ
+		}
+	}
+	return result
+}
+
+// ChangeKind says how a declaration changed.
+type ChangeKind string
+
+const (
+	Added    ChangeKind = "added"
+	Removed  ChangeKind = "removed"
+	Changed  ChangeKind = "changed" // The signature or definition changed.
+	BodyOnly ChangeKind = "body"    // Only the implementation or value changed.
+)
+
+// Change is a declaration that differs between two versions of a file.
+type Change struct {
+	Kind     ChangeKind
+	Old, New *Decl
+}
+
+// String describes the change on a single line.
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return "added " + c.New.String()
+	case Removed:
+		return "removed " + c.Old.String()
+	case Changed:
+		if c.Old.Signature == c.New.Signature {
+			if diff := memberChanges(c.Old, c.New); diff != "" {
+				return "changed " + c.New.String() + ": " + diff
+			}
+			return "changed definition of " + c.New.String()
+		}
+		return "changed " + c.Old.String() + " -> " + c.New.String()
+	}
+	if c.New.Kind == "var" || c.New.Kind == "const" {
+		return "changed value of " + c.New.String()
+	}
+	return "changed body of " + c.New.String()
+}
+
+// Compare lists the declarations added, removed or changed between two versions
+// of a file, in declaration name order. Either version may be nil.
+func Compare(old, new *File) []Change {
+	var oldDecls, newDecls map[string]Decl
+	if old != nil {
+		oldDecls = old.Decls
+	}
+	if new != nil {
+		newDecls = new.Decls
+	}
+
+	var changes []Change
+	for name, o := range oldDecls {
+		o := o
+		n, ok := newDecls[name]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: Removed, Old: &o})
+		case o.Signature != n.Signature || (o.Kind == "type" && o.Body != n.Body):
+			changes = append(changes, Change{Kind: Changed, Old: &o, New: &n})
+		case o.Body != n.Body:
+			changes = append(changes, Change{Kind: BodyOnly, Old: &o, New: &n})
+		}
+	}
+	for name, n := range newDecls {
+		n := n
+		if _, ok := oldDecls[name]; !ok {
+			changes = append(changes, Change{Kind: Added, New: &n})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].name() < changes[j].name()
+	})
+	return changes
+}
+
+// memberChanges describes the fields or interface methods that differ
+// between two versions of a type, or returns "" if it has no members.
+func memberChanges(old, new *Decl) string {
+	if old.Members == nil || new.Members == nil {
+		return ""
+	}
+	member, describe := "field", func(name, typ string) string { return name + " " + typ }
+	if strings.HasSuffix(new.Signature, " interface") {
+		member, describe = "method", func(name, typ string) string { return name + strings.TrimPrefix(typ, "func") }
+	}
+
+	var parts []string
+	for _, name := range sortedKeys(old.Members) {
+		typ, ok := new.Members[name]
+		switch {
+		case !ok:
+			parts = append(parts, fmt.Sprintf("removed %s %s", member, name))
+		case typ != old.Members[name]:
+			parts = append(parts, fmt.Sprintf("changed %s %s -> %s", member, describe(name, old.Members[name]), describe(name, typ)))
+		}
+	}
+	for _, name := range sortedKeys(new.Members) {
+		if _, ok := old.Members[name]; !ok {
+			parts = append(parts, fmt.Sprintf("added %s %s", member, describe(name, new.Members[name])))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sortedKeys returns the keys of a map in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// name returns the name of the changed declaration.
+func (c Change) name() string {
+	if c.New != nil {
+		return c.New.Name
+	}
+	return c.Old.Name
+}
+
+// FileChanges holds the declaration changes of one staged Go file.
+type FileChanges struct {
+	Path    string
+	Package string
+	Changes []Change
+	Err     error // set when a version of the file could not be parsed
+}
+
+// BodyOnly reports whether only implementations or values changed in the
+// file, which the summary names but does not describe.
+func (fc FileChanges) BodyOnly() bool {
+	for _, change := range fc.Changes {
+		if change.Kind != BodyOnly {
+			return false
+		}
+	}
+	return fc.Err == nil
+}
+
+// Summarize compares the base and index versions of the staged Go files,
+// following renames. Other files are ignored.
+func Summarize(staged *git.DiffResult) ([]FileChanges, error) {
+	var result []FileChanges
+	for _, change := range staged.Files {
+		file := change.Path
+		if path.Ext(file) != ".go" || change.Binary {
+			continue
+		}
+
+		// A copy leaves its source in place, so all of it is new
+		var old []byte
+		var err error
+		if change.Status != 'A' && change.Status != 'C' {
+			if old, err = load(staged.Base, change.OldPath); err != nil {
+				return nil, err
+			}
+		}
+		new, err := load("", file)
+		if err != nil {
+			return nil, err
+		}
+
+		fc := FileChanges{Path: file}
+		oldFile, oldErr := parse(file, old)
+		newFile, newErr := parse(file, new)
+		switch {
+		case newErr != nil:
+			fc.Err = newErr
+		case oldErr != nil:
+			fc.Err = oldErr
+		default:
+			fc.Changes = Compare(oldFile, newFile)
+		}
+		if newFile != nil {
+			fc.Package = newFile.Package
+		} else if oldFile != nil {
+			fc.Package = oldFile.Package
+		}
+		if fc.Err == nil && len(fc.Changes) == 0 {
+			continue
+		}
+		result = append(result, fc)
+	}
+	return result, nil
+}
+
+// load reads a version of a file; nil means it does not exist there.
+func load(rev, file string) ([]byte, error) {
+	src, ok, err := git.ShowFile(rev, file)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return src, nil
+}
+
+// parse parses a version of a file, or returns nil if it does not exist.
+func parse(file string, src []byte) (*File, error) {
+	if src == nil {
+		return nil, nil
+	}
+	return Parse(file, src)
+}
+
+// Format renders the summary for the prompt. It is empty when no Go
+// declarations changed.
+func Format(files []FileChanges) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Go declarations changed:\n")
+	for _, fc := range files {
+		fmt.Fprintf(&b, "%s (package %s)\n", fc.Path, fc.Package)
+		if fc.Err != nil {
+			b.WriteString("  could not be parsed\n")
+			continue
+		}
+		for _, change := range fc.Changes {
+			fmt.Fprintf(&b, "  %s\n", change)
+		}
+	}
+
+	summary := strings.TrimSuffix(b.String(), "\n")
+	if len(summary) > maxSummarySize {
+		summary = summary[:maxSummarySize] + "\n[...truncated]"
+	}
+	return summary
+}
@@ -0,0 +1,223 @@
+package goast
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/tolgaOzen/combo/pkg/git"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string // nil file when empty
+		new  string
+		want []string
+	}{
+		{
+			name: "added file",
+			new:  "func Retry(n int) error { return nil }\ntype client struct{}",
+			want: []string{"added func Retry(n int) error", "added type client struct"},
+		},
+		{
+			name: "removed file",
+			old:  "const Max = 3\nvar max = 3",
+			want: []string{"removed const Max"},
+		},
+		{
+			name: "changed signature",
+			old:  "func Retry(n int) error { return nil }",
+			new:  "func Retry(ctx context.Context, n int) error { return nil }",
+			want: []string{"changed func Retry(n int) error -> func Retry(ctx context.Context, n int) error"},
+		},
+		{
+			name: "changed body",
+			old:  "func Retry(n int) error { return nil }",
+			new:  "func Retry(n int) error { return errRetry }",
+			want: []string{"changed body of func Retry(n int) error"},
+		},
+		{
+			name: "changed method body",
+			old:  "func (c *Client) Do() error { return nil }",
+			new:  "func (c *Client) Do() error { return c.err }",
+			want: []string{"changed body of method (*Client).Do() error"},
+		},
+		{
+			name: "changed value",
+			old:  "const Max = 3",
+			new:  "const Max = 5",
+			want: []string{"changed value of const Max"},
+		},
+		{
+			name: "struct fields",
+			old:  "type Client struct {\n\tName string\n\tSize int\n\tOld bool\n}",
+			new:  "type Client struct {\n\tName string\n\tSize int64\n\tRetry int\n}",
+			want: []string{"changed type Client struct: removed field Old, changed field Size int -> Size int64, added field Retry int"},
+		},
+		{
+			name: "interface methods",
+			old:  "type Store interface {\n\tGet(key string) string\n}",
+			new:  "type Store interface {\n\tGet(key string) (string, bool)\n\tSet(key, value string)\n}",
+			want: []string{"changed type Store interface: changed method Get(key string) string -> Get(key string) (string, bool), added method Set(key, value string)"},
+		},
+		{
+			name: "type kind",
+			old:  "type ID struct{ Value string }",
+			new:  "type ID string",
+			want: []string{"changed type ID struct -> type ID"},
+		},
+		{
+			name: "alias",
+			old:  "type ID = string",
+			new:  "type ID = int",
+			want: []string{"changed definition of type ID (alias)"},
+		},
+		{
+			name: "sorted by name",
+			old:  "func B() {}\nfunc C() {}",
+			new:  "func A() {}\nfunc C() { return }",
+			want: []string{"added func A()", "removed func B()", "changed body of func C()"},
+		},
+		{
+			name: "comments only",
+			old:  "// Retry retries.\nfunc Retry() {}",
+			new:  "// Retry tries again.\nfunc Retry() {\n\t// nothing to do\n}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, change := range Compare(parseFile(t, tt.old), parseFile(t, tt.new)) {
+				got = append(got, change.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compare() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// parseFile parses the declarations of a file in package a, or returns nil
+// for an empty source.
+func parseFile(t *testing.T, decls string) *File {
+	t.Helper()
+	if decls == "" {
+		return nil
+	}
+	file, err := Parse("a.go", []byte("package a\n"+decls))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// stagedChange commits the base files to a new repository, stages the index
+// files on top, where an empty content deletes the file, and returns the
+// staged change. The repository is used by the git package until the test ends.
+func stagedChange(t *testing.T, base, index map[string]string) *git.DiffResult {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	git.Use(git.NewGoGitRepository(repo))
+	t.Cleanup(func() { git.Use(git.NewExecRepository("")) })
+
+	stage := func(files map[string]string) {
+		for name, content := range files {
+			if content == "" {
+				if _, err := worktree.Remove(name); err != nil {
+					t.Fatal(err)
+				}
+				continue
+			}
+			path := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := worktree.Add(name); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	stage(base)
+	signature := &object.Signature{Name: "Test", Email: "test@example.com", When: time.Unix(1700000000, 0)}
+	if _, err := worktree.Commit("initial", &gogit.CommitOptions{Author: signature, Committer: signature}); err != nil {
+		t.Fatal(err)
+	}
+	stage(index)
+
+	staged, err := git.FetchStagedDiff()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return staged
+}
+
+func TestSummarize(t *testing.T) {
+	staged := stagedChange(t,
+		map[string]string{
+			"client.go":    "package api\n\nfunc Get() error { return nil }\n",
+			"old.go":       "package api\n\nfunc Old() {}\n",
+			"same.go":      "package api\n\n// Same is unchanged.\nfunc Same() {}\n",
+			"README.md":    "# api\n",
+			"broken.go":    "package api\n",
+			"util/util.go": "package util\n\nfunc Helper() int { return 1 }\n",
+		},
+		map[string]string{
+			"client.go":    "package api\n\nfunc Get(id string) error { return nil }\n",
+			"old.go":       "",
+			"same.go":      "package api\n\n// Same is still unchanged.\nfunc Same() {}\n",
+			"README.md":    "# api\n\nDocs.\n",
+			"broken.go":    "package api\n\nfunc {\n",
+			"new.go":       "package api\n\ntype Options struct{ Retries int }\n",
+			"util/util.go": "package util\n\nfunc Helper() int { return 2 }\n",
+		})
+
+	files, err := Summarize(staged)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string][]string)
+	for _, fc := range files {
+		if fc.Err != nil {
+			got[fc.Path] = []string{"error"}
+			continue
+		}
+		for _, change := range fc.Changes {
+			got[fc.Path] = append(got[fc.Path], fc.Package+": "+change.String())
+		}
+	}
+	want := map[string][]string{
+		"broken.go":    {"error"},
+		"client.go":    {"api: changed func Get() error -> func Get(id string) error"},
+		"new.go":       {"api: added type Options struct"},
+		"old.go":       {"api: removed func Old()"},
+		"util/util.go": {"util: changed body of func Helper() int"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Summarize() = %q, want %q", got, want)
+	}
+
+	for _, fc := range files {
+		if want := fc.Path == "util/util.go"; fc.BodyOnly() != want {
+			t.Errorf("%s: BodyOnly() = %v, want %v", fc.Path, fc.BodyOnly(), want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package history
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tolgaOzen/combo/pkg/git"
+)
+
+// Selection defines how past commits are picked as examples.
+type Selection string
+
+const (
+	Recent  Selection = "recent"  // The latest commits.
+	Similar Selection = "similar" // The commits that touched the same paths as the staged change.
+)
+
+func (s Selection) String() string {
+	return string(s)
+}
+
+// ParseSelection converts a configuration value into a Selection.
+func ParseSelection(value string) (Selection, error) {
+	switch Selection(value) {
+	case Recent, Similar:
+		return Selection(value), nil
+	}
+	return "", fmt.Errorf("must be one of %s, %s, got %q", Recent, Similar, value)
+}
+
+// poolSize is how many commits are considered when looking for examples.
+const poolSize = 200
+
+// maxExampleLength caps each example, so a few long messages cannot crowd out the diff.
+const maxExampleLength = 500
+
+// revertPattern matches the subjects of reverts, as written by git or by hand.
+var revertPattern = regexp.MustCompile(`(?i)^(revert\b|revert(\([^)]*\))?!?:)`)
+
+// Examples returns the messages of up to n past commits to show the model as
+// examples, skipping merges and reverts. Similar selection prefers commits that
+// touched the staged files or their directories, topping up with recent ones.
+func Examples(n int, selection Selection, staged []string) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	commits, err := git.History(poolSize)
+	if err != nil {
+		return nil, err
+	}
+	return selectExamples(commits, n, selection, staged), nil
+}
+
+// selectExamples picks the messages of up to n of the commits, newest first.
+func selectExamples(commits []git.Commit, n int, selection Selection, staged []string) []string {
+	var candidates []git.Commit
+	for _, c := range commits {
+		if !c.Merge && !revertPattern.MatchString(c.Subject()) && c.Message != "" {
+			candidates = append(candidates, c)
+		}
+	}
+
+	if selection == Similar {
+		candidates = bySimilarity(candidates, staged)
+	}
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+
+	examples := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		message := c.Message
+		if runes := []rune(message); len(runes) > maxExampleLength {
+			message = strings.TrimSpace(string(runes[:maxExampleLength])) + "\n[...]"
+		}
+		examples = append(examples, message)
+	}
+	return examples
+}
+
+// bySimilarity orders commits by how much their paths overlap with the staged
+// files: a shared file counts double a shared directory. Ties keep the newest first.
+func bySimilarity(commits []git.Commit, staged []string) []git.Commit {
+	files := make(map[string]bool, len(staged))
+	dirs := make(map[string]bool, len(staged))
+	for _, file := range staged {
+		files[file] = true
+		dirs[path.Dir(file)] = true
+	}
+
+	scores := make(map[string]int, len(commits))
+	for _, c := range commits {
+		seenDirs := make(map[string]bool)
+		for _, file := range c.Files {
+			if files[file] {
+				scores[c.Hash] += 2
+			}
+			if dir := path.Dir(file); dirs[dir] && !seenDirs[dir] {
+				seenDirs[dir] = true
+				scores[c.Hash]++
+			}
+		}
+	}
+
+	sorted := append([]git.Commit(nil), commits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return scores[sorted[i].Hash] > scores[sorted[j].Hash]
+	})
+	return sorted
+}
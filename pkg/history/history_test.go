@@ -0,0 +1,114 @@
+package history
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tolgaOzen/combo/pkg/git"
+)
+
+func TestRevertPattern(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    bool
+	}{
+		{subject: `Revert "feat: add login"`, want: true},
+		{subject: "revert: feat: add login", want: true},
+		{subject: "revert(auth)!: drop the login form", want: true},
+		{subject: "REVERT add login", want: true},
+		{subject: "revert", want: true},
+		{subject: "reverted the login change", want: false},
+		{subject: "fix: revert the login change", want: false},
+		{subject: "feat: add login", want: false},
+	}
+	for _, tt := range tests {
+		if got := revertPattern.MatchString(tt.subject); got != tt.want {
+			t.Errorf("revertPattern.MatchString(%q) = %v, want %v", tt.subject, got, tt.want)
+		}
+	}
+}
+
+func TestSelectExamples(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "1", Message: "feat: add login"},
+		{Hash: "2", Message: "Merge branch 'main'", Merge: true},
+		{Hash: "3", Message: `Revert "feat: add signup"`},
+		{Hash: "4", Message: ""},
+		{Hash: "5", Message: "revert(auth): drop signup"},
+		{Hash: "6", Message: "fix: handle nil"},
+		{Hash: "7", Message: "docs: describe login"},
+	}
+
+	tests := []struct {
+		name string
+		n    int
+		want []string
+	}{
+		{name: "merges, reverts and empty messages are skipped", n: 10, want: []string{"feat: add login", "fix: handle nil", "docs: describe login"}},
+		{name: "newest first", n: 2, want: []string{"feat: add login", "fix: handle nil"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectExamples(commits, tt.n, Recent, nil)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectExamples() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectExamplesTruncates(t *testing.T) {
+	long := "feat: add login\n\n" + strings.Repeat("é", maxExampleLength)
+	got := selectExamples([]git.Commit{{Hash: "1", Message: long}, {Hash: "2", Message: "fix: handle nil"}}, 2, Recent, nil)
+
+	if len(got) != 2 {
+		t.Fatalf("selectExamples() returned %d examples, want 2", len(got))
+	}
+	want := strings.TrimSpace(string([]rune(long)[:maxExampleLength])) + "\n[...]"
+	if got[0] != want {
+		t.Errorf("long example = %q, want %q", got[0], want)
+	}
+	if got[1] != "fix: handle nil" {
+		t.Errorf("short example = %q", got[1])
+	}
+}
+
+func TestBySimilarity(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "a", Files: []string{"README.md"}},                          // 0
+		{Hash: "b", Files: []string{"pkg/git/other.go"}},                   // same directory: 1
+		{Hash: "c", Files: []string{"pkg/git/diff.go"}},                    // same file: 2 + 1
+		{Hash: "d", Files: []string{"pkg/git/a.go", "pkg/git/b.go"}},       // a directory counts once: 1
+		{Hash: "e", Files: []string{"pkg/git/diff.go", "pkg/cmd/root.go"}}, // 2 + 1 + 1
+		{Hash: "f", Files: []string{"docs/index.md"}},                      // 0
+	}
+	staged := []string{"pkg/git/diff.go", "pkg/cmd/commit.go"}
+
+	var got []string
+	for _, c := range bySimilarity(commits, staged) {
+		got = append(got, c.Hash)
+	}
+	// Ties keep the newest first
+	want := []string{"e", "c", "b", "d", "a", "f"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bySimilarity() = %q, want %q", got, want)
+	}
+	if commits[0].Hash != "a" {
+		t.Error("bySimilarity reordered its argument")
+	}
+}
+
+func TestSelectExamplesSimilar(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "1", Message: "docs: update the README", Files: []string{"README.md"}},
+		{Hash: "2", Message: `Revert "fix(git): parse renames"`, Files: []string{"pkg/git/diff.go"}},
+		{Hash: "3", Message: "fix(git): parse renames", Files: []string{"pkg/git/diff.go"}},
+		{Hash: "4", Message: "feat: add squash", Files: []string{"pkg/cmd/squash.go"}},
+	}
+	got := selectExamples(commits, 2, Similar, []string{"pkg/git/diff.go"})
+	want := []string{"fix(git): parse renames", "docs: update the README"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("selectExamples() = %q, want %q", got, want)
+	}
+}
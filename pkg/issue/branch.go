@@ -0,0 +1,44 @@
+package issue
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// DefaultBranchTemplate uses the generated name as is.
+const DefaultBranchTemplate = "{{.Name}}"
+
+// BranchData holds the values available to the branch name template.
+type BranchData struct {
+	Name  string // Name generated from the staged changes.
+	Issue string // Issue key resolved for the branch, if any.
+}
+
+// emptySegments matches separators left behind by empty template values.
+var emptySegments = regexp.MustCompile(`([-_/])[-_/]+`)
+
+// RenderBranchName renders the branch template, e.g. "feat/{{.Issue}}-{{.Name}}".
+// A "#" in the issue key is dropped since it is awkward in ref names.
+func RenderBranchName(tmpl string, data BranchData) (string, error) {
+	if tmpl == "" {
+		tmpl = DefaultBranchTemplate
+	}
+
+	t, err := template.New("branch").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid branch template: %w", err)
+	}
+
+	data.Name = strings.TrimSpace(data.Name)
+	data.Issue = strings.TrimPrefix(data.Issue, "#")
+
+	var out strings.Builder
+	if err := t.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render branch template: %w", err)
+	}
+
+	name := emptySegments.ReplaceAllString(out.String(), "$1")
+	return strings.Trim(name, "-_/"), nil
+}
@@ -0,0 +1,40 @@
+package issue
+
+import "testing"
+
+func TestRenderBranchName(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    string
+		data    BranchData
+		want    string
+		wantErr bool
+	}{
+		{name: "default template", data: BranchData{Name: " feat/add-login ", Issue: "PROJ-1"}, want: "feat/add-login"},
+		{name: "issue and name", tmpl: "feat/{{.Issue}}-{{.Name}}", data: BranchData{Name: "add-login", Issue: "PROJ-1"}, want: "feat/PROJ-1-add-login"},
+		{name: "github issue drops the hash", tmpl: "{{.Issue}}-{{.Name}}", data: BranchData{Name: "fix-crash", Issue: "#45"}, want: "45-fix-crash"},
+		{name: "empty issue collapses separators", tmpl: "feat/{{.Issue}}-{{.Name}}", data: BranchData{Name: "add-login"}, want: "feat/add-login"},
+		{name: "empty issue at the end", tmpl: "{{.Name}}_{{.Issue}}", data: BranchData{Name: "add-login"}, want: "add-login"},
+		{name: "empty issue at the start", tmpl: "{{.Issue}}/{{.Name}}", data: BranchData{Name: "add-login"}, want: "add-login"},
+		{name: "template functions", tmpl: `{{if .Issue}}{{.Issue}}/{{end}}{{.Name}}`, data: BranchData{Name: "add-login", Issue: "PROJ-1"}, want: "PROJ-1/add-login"},
+		{name: "unknown field", tmpl: "{{.Ticket}}-{{.Name}}", data: BranchData{Name: "add-login"}, wantErr: true},
+		{name: "invalid template", tmpl: "{{.Name", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderBranchName(tt.tmpl, tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("RenderBranchName(%q) = %q, want an error", tt.tmpl, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderBranchName(%q) = %q, want %q", tt.tmpl, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package issue
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultPattern matches tracker keys such as JIRA-123 or #456.
+const DefaultPattern = `[A-Z][A-Z0-9]+-[0-9]+|#[0-9]+`
+
+// Format defines how an issue key is attached to a commit message.
+type Format string
+
+const (
+	Refs   Format = "refs"   // Appends a "Refs: <key>" footer.
+	Closes Format = "closes" // Appends a "Closes: <key>" footer.
+	Prefix Format = "prefix" // Starts the subject with "<key> ", after any conventional commit type.
+	None   Format = "none"   // Leaves the message untouched.
+)
+
+func (f Format) String() string {
+	return string(f)
+}
+
+// ParseFormat validates a format name from the configuration.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case Refs, Closes, Prefix, None:
+		return f, nil
+	case "":
+		return Refs, nil
+	default:
+		return "", fmt.Errorf("invalid issue reference format: %s (expected refs, closes, prefix or none)", s)
+	}
+}
+
+// Resolve finds an issue key in a branch name. If the pattern contains a
+// capture group, the first group is used as the key. Bare numbers are
+// returned as "#<number>". An empty key means no match.
+func Resolve(branch, pattern string) (string, error) {
+	if pattern == "" {
+		pattern = DefaultPattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid issue pattern %q: %w", pattern, err)
+	}
+
+	match := re.FindStringSubmatch(branch)
+	if match == nil {
+		return "", nil
+	}
+
+	key := match[0]
+	if len(match) > 1 {
+		key = match[1]
+	}
+	if key == "" {
+		return "", nil
+	}
+
+	if strings.Trim(key, "0123456789") == "" {
+		key = "#" + key
+	}
+
+	return key, nil
+}
+
+// conventionalHeader matches the "type(scope)!: " start of a conventional commit subject.
+var conventionalHeader = regexp.MustCompile(`^[a-zA-Z]+(?:\([^()]*\))?!?:[ \t]*`)
+
+// Apply attaches the issue key to message using the given format.
+// Messages that already mention the key are returned unchanged.
+func Apply(message, key string, format Format) string {
+	message = strings.TrimSpace(message)
+	if key == "" || mentions(message, key) {
+		return message
+	}
+
+	switch format {
+	case Refs:
+		return message + "\n\nRefs: " + key
+	case Closes:
+		return message + "\n\nCloses: " + key
+	case Prefix:
+		// Keep a conventional commit header first, e.g. "feat(api): KEY-1 add login"
+		if header := conventionalHeader.FindString(message); header != "" {
+			return header + key + " " + message[len(header):]
+		}
+		return key + " " + message
+	default:
+		return message
+	}
+}
+
+// mentions reports whether message contains key as a whole word, so that
+// #45 is not taken for #456, nor PROJ-1 for PROJ-12.
+func mentions(message, key string) bool {
+	re := regexp.MustCompile(`(?:^|[^\w/#-])` + regexp.QuoteMeta(key) + `(?:$|\W)`)
+	return re.MatchString(message)
+}
@@ -0,0 +1,92 @@
+package issue
+
+import "testing"
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name    string
+		branch  string
+		pattern string
+		want    string
+		wantErr bool
+	}{
+		{name: "jira key", branch: "feature/PROJ-123-login", want: "PROJ-123"},
+		{name: "github issue", branch: "fix/#456-crash", want: "#456"},
+		{name: "first key wins", branch: "PROJ-1-and-PROJ-2", want: "PROJ-1"},
+		{name: "no key", branch: "main", want: ""},
+		{name: "lowercase is not a key", branch: "proj-123", want: ""},
+		{name: "capture group", branch: "user/42-login", pattern: `^user/(\d+)-`, want: "#42"},
+		{name: "bare number becomes a github issue", branch: "789-login", pattern: `^\d+`, want: "#789"},
+		{name: "capture group with a key", branch: "task_ABC-7", pattern: `task_([A-Z]+-\d+)`, want: "ABC-7"},
+		{name: "empty match", branch: "main", pattern: `\d*`, want: ""},
+		{name: "empty group", branch: "feature/login", pattern: `feature/(\d*)`, want: ""},
+		{name: "invalid pattern", branch: "main", pattern: `(`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(tt.branch, tt.pattern)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Resolve(%q, %q) succeeded, want an error", tt.branch, tt.pattern)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve(%q, %q) = %q, want %q", tt.branch, tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		key     string
+		format  Format
+		want    string
+	}{
+		{name: "refs", message: "feat: add login", key: "PROJ-1", format: Refs, want: "feat: add login\n\nRefs: PROJ-1"},
+		{name: "closes", message: "fix: crash\n\nBody.", key: "#45", format: Closes, want: "fix: crash\n\nBody.\n\nCloses: #45"},
+		{name: "none", message: "feat: add login", key: "PROJ-1", format: None, want: "feat: add login"},
+		{name: "no key", message: "feat: add login", key: "", format: Refs, want: "feat: add login"},
+		{name: "trims the message", message: "  feat: add login\n\n", key: "PROJ-1", format: Refs, want: "feat: add login\n\nRefs: PROJ-1"},
+
+		{name: "prefix after conventional type", message: "feat: add login", key: "PROJ-1", format: Prefix, want: "feat: PROJ-1 add login"},
+		{name: "prefix after scope and bang", message: "feat(auth)!: drop tokens", key: "#45", format: Prefix, want: "feat(auth)!: #45 drop tokens"},
+		{name: "prefix plain subject", message: "Add login\n\nBody.", key: "PROJ-1", format: Prefix, want: "PROJ-1 Add login\n\nBody."},
+		{name: "prefix gitmoji subject", message: "✨ Add login", key: "PROJ-1", format: Prefix, want: "PROJ-1 ✨ Add login"},
+
+		{name: "already mentioned", message: "fix: crash in PROJ-1 handler", key: "PROJ-1", format: Refs, want: "fix: crash in PROJ-1 handler"},
+		{name: "already in a footer", message: "fix: crash\n\nRefs: #45", key: "#45", format: Closes, want: "fix: crash\n\nRefs: #45"},
+		{name: "mention at the end of a sentence", message: "fix: crash, see #45.", key: "#45", format: Refs, want: "fix: crash, see #45."},
+		{name: "longer github issue", message: "fix: crash\n\nRefs: #456", key: "#45", format: Refs, want: "fix: crash\n\nRefs: #456\n\nRefs: #45"},
+		{name: "longer jira key", message: "fix: crash\n\nRefs: PROJ-12", key: "PROJ-1", format: Refs, want: "fix: crash\n\nRefs: PROJ-12\n\nRefs: PROJ-1"},
+		{name: "longer project", message: "fix: crash\n\nRefs: SUBPROJ-1", key: "PROJ-1", format: Refs, want: "fix: crash\n\nRefs: SUBPROJ-1\n\nRefs: PROJ-1"},
+		{name: "issue of another repository", message: "fix: crash\n\nRefs: org/repo#45", key: "#45", format: Refs, want: "fix: crash\n\nRefs: org/repo#45\n\nRefs: #45"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Apply(tt.message, tt.key, tt.format); got != tt.want {
+				t.Errorf("Apply(%q, %q, %s) = %q, want %q", tt.message, tt.key, tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, s := range []string{"refs", "closes", "prefix", "none"} {
+		if f, err := ParseFormat(s); err != nil || f.String() != s {
+			t.Errorf("ParseFormat(%q) = %q, %v", s, f, err)
+		}
+	}
+	if f, err := ParseFormat(""); err != nil || f != Refs {
+		t.Errorf("ParseFormat(\"\") = %q, %v; want refs", f, err)
+	}
+	if _, err := ParseFormat("footer"); err == nil {
+		t.Error("ParseFormat accepted an unknown format")
+	}
+}
@@ -0,0 +1,60 @@
+package prompt
+
+import "strings"
+
+// GitmojiType is an emoji that states the intent of a commit, see https://gitmoji.dev.
+type GitmojiType struct {
+	Emoji       string
+	Code        string
+	Description string
+}
+
+// gitmojis lists the gitmojis offered to the model.
+var gitmojis = []GitmojiType{
+	{"🎨", ":art:", "Improve structure / format of the code."},
+	{"⚡️", ":zap:", "Improve performance."},
+	{"🔥", ":fire:", "Remove code or files."},
+	{"🐛", ":bug:", "Fix a bug."},
+	{"🚑️", ":ambulance:", "Critical hotfix."},
+	{"✨", ":sparkles:", "Introduce new features."},
+	{"📝", ":memo:", "Add or update documentation."},
+	{"🚀", ":rocket:", "Deploy stuff."},
+	{"💄", ":lipstick:", "Add or update the UI and style files."},
+	{"🎉", ":tada:", "Begin a project."},
+	{"✅", ":white_check_mark:", "Add, update, or pass tests."},
+	{"🔒️", ":lock:", "Fix security or privacy issues."},
+	{"🔖", ":bookmark:", "Release / Version tags."},
+	{"🚨", ":rotating_light:", "Fix compiler / linter warnings."},
+	{"🚧", ":construction:", "Work in progress."},
+	{"💚", ":green_heart:", "Fix CI Build."},
+	{"⬇️", ":arrow_down:", "Downgrade dependencies."},
+	{"⬆️", ":arrow_up:", "Upgrade dependencies."},
+	{"👷", ":construction_worker:", "Add or update CI build system."},
+	{"♻️", ":recycle:", "Refactor code."},
+	{"➕", ":heavy_plus_sign:", "Add a dependency."},
+	{"➖", ":heavy_minus_sign:", "Remove a dependency."},
+	{"🔧", ":wrench:", "Add or update configuration files."},
+	{"🌐", ":globe_with_meridians:", "Internationalization and localization."},
+	{"✏️", ":pencil2:", "Fix typos."},
+	{"⏪️", ":rewind:", "Revert changes."},
+	{"🏷️", ":label:", "Add or update types."},
+	{"🗑️", ":wastebasket:", "Deprecate code that needs to be cleaned up."},
+	{"💥", ":boom:", "Introduce breaking changes."},
+}
+
+// GitmojiTypes returns every gitmoji the model can choose from.
+func GitmojiTypes() []GitmojiType {
+	return append([]GitmojiType(nil), gitmojis...)
+}
+
+// LookupGitmoji finds a gitmoji by its emoji, with or without the variation
+// selector, or by its :code:.
+func LookupGitmoji(value string) (GitmojiType, bool) {
+	bare := strings.TrimSuffix(value, "️")
+	for _, g := range gitmojis {
+		if bare == strings.TrimSuffix(g.Emoji, "️") || value == g.Code {
+			return g, true
+		}
+	}
+	return GitmojiType{}, false
+}
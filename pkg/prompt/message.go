@@ -0,0 +1,278 @@
+package prompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// OutputFormat defines how the model is asked to return a commit message.
+type OutputFormat string
+
+const (
+	Text       OutputFormat = "text"        // Free-form text in the commit format.
+	JSON       OutputFormat = "json"        // A JSON object, using the API's JSON mode.
+	JSONSchema OutputFormat = "json_schema" // A JSON object enforced by a strict response schema.
+)
+
+func (f OutputFormat) String() string {
+	return string(f)
+}
+
+// CommitMessage is a commit message split into its conventional commit fields.
+type CommitMessage struct {
+	Type     string   `json:"type"`
+	Scope    string   `json:"scope"`
+	Subject  string   `json:"subject"`
+	Body     string   `json:"body"`
+	Breaking bool     `json:"breaking"`
+	Footers  []string `json:"footers"`
+}
+
+// CommitMessageSchema is the JSON schema of CommitMessage, in the strict form
+// accepted by structured outputs: every field required, nothing else allowed.
+var CommitMessageSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "type": {"type": "string", "description": "commit type, empty for plain messages"},
+    "scope": {"type": "string", "description": "area of the code base affected, empty if none"},
+    "subject": {"type": "string", "description": "short imperative summary"},
+    "body": {"type": "string", "description": "longer explanation, empty if none"},
+    "breaking": {"type": "boolean", "description": "whether the change breaks backwards compatibility"},
+    "footers": {"type": "array", "items": {"type": "string"}, "description": "git trailers such as \"Refs: #12\""}
+  },
+  "required": ["type", "scope", "subject", "body", "breaking", "footers"],
+  "additionalProperties": false
+}`)
+
+// specifyJSONFormat describes the JSON object the model must return.
+func specifyJSONFormat(style CommitStyle) string {
+	typeField := `"type": always an empty string`
+	scopeField := `"scope": the area of the code base affected, or an empty string`
+	switch style {
+	case Conventional:
+		typeField = `"type": one of the types above`
+	case Gitmoji:
+		typeField = `"type": one of the gitmojis above`
+		scopeField = `"scope": always an empty string`
+	}
+	return fmt.Sprintf(`The output response must be a JSON object with these fields:
+%s
+%s
+"subject": a short imperative summary starting with lowercase, without a trailing period
+"body": a longer explanation wrapped at 72 characters, or an empty string
+"breaking": true only if the change breaks backwards compatibility
+"footers": git trailers such as "Refs: #12", usually an empty array`, typeField, scopeField)
+}
+
+// ParseCommitMessage reads the model's answer. JSON answers are decoded and
+// checked field by field, repairing or dropping fields that fail validation;
+// anything else is parsed as a free-form message after stripping the quotes
+// and code fences models like to add. An unknown commit type is kept, so that
+// Validate reports it. The subject is empty if the answer holds no message.
+func ParseCommitMessage(content string, style CommitStyle) CommitMessage {
+	text := unwrap(content)
+
+	var msg CommitMessage
+	if strings.HasPrefix(text, "{") && json.Unmarshal([]byte(text), &msg) == nil {
+		msg.normalize()
+		msg.repair(style)
+		return msg
+	}
+
+	return parseFreeForm(text, style)
+}
+
+// repair drops or fixes the fields that would fail Validate, except for an
+// unknown commit type, which cannot be guessed.
+func (m *CommitMessage) repair(style CommitStyle) {
+	if subject, rest, ok := strings.Cut(m.Subject, "\n"); ok {
+		m.Subject = strings.TrimSpace(subject)
+		m.Body = strings.TrimSpace(strings.TrimSpace(rest) + "\n\n" + m.Body)
+	}
+	if t, known := canonicalType(style, m.Type); known || style == Empty {
+		m.Type = t
+	}
+	if strings.ContainsAny(m.Scope, "()\n") {
+		m.Scope = ""
+	}
+
+	footers := m.Footers[:0]
+	for _, footer := range m.Footers {
+		if footerPattern.MatchString(footer) {
+			footers = append(footers, footer)
+		}
+	}
+	m.Footers = footers
+}
+
+// canonicalType returns the spelling of a commit type used by the style,
+// turning gitmoji codes into emoji, and whether the style knows the type.
+// Plain messages have no type.
+func canonicalType(style CommitStyle, value string) (string, bool) {
+	switch style {
+	case Conventional:
+		if _, known := commitTypeDescriptions[CommitType(strings.ToLower(value))]; known {
+			return strings.ToLower(value), true
+		}
+	case Gitmoji:
+		if g, known := LookupGitmoji(value); known {
+			return g.Emoji, true
+		}
+	}
+	return "", false
+}
+
+// conventionalHeader matches "type(scope)!: subject".
+var conventionalHeader = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^()]*)\))?(!)?:\s*(.+)$`)
+
+// footerPattern matches a git trailer or a BREAKING CHANGE footer.
+var footerPattern = regexp.MustCompile(`^(?:[A-Za-z][A-Za-z0-9-]*|BREAKING CHANGE):\s+.+$`)
+
+// parseFreeForm splits a plain text commit message into its fields.
+func parseFreeForm(text string, style CommitStyle) CommitMessage {
+	header, rest, _ := strings.Cut(text, "\n")
+
+	var msg CommitMessage
+	msg.Subject = strings.TrimSpace(header)
+	switch style {
+	case Conventional:
+		if match := conventionalHeader.FindStringSubmatch(msg.Subject); match != nil {
+			msg.Type = match[1]
+			if t, known := canonicalType(style, match[1]); known {
+				msg.Type = t
+			}
+			msg.Scope = match[2]
+			msg.Breaking = match[3] == "!"
+			msg.Subject = match[4]
+		}
+	case Gitmoji:
+		if first, subject, ok := strings.Cut(msg.Subject, " "); ok {
+			if t, known := canonicalType(style, first); known {
+				msg.Type = t
+				msg.Subject = strings.TrimSpace(subject)
+			}
+		}
+	}
+
+	// A final paragraph made only of footers is kept apart from the body
+	paragraphs := strings.Split(strings.TrimSpace(rest), "\n\n")
+	if last := paragraphs[len(paragraphs)-1]; last != "" && isFooterBlock(last) {
+		for _, line := range strings.Split(last, "\n") {
+			msg.Footers = append(msg.Footers, strings.TrimSpace(line))
+		}
+		paragraphs = paragraphs[:len(paragraphs)-1]
+	}
+	msg.Body = strings.Join(paragraphs, "\n\n")
+
+	msg.normalize()
+	return msg
+}
+
+// isFooterBlock reports whether every line of the paragraph is a footer.
+func isFooterBlock(paragraph string) bool {
+	for _, line := range strings.Split(paragraph, "\n") {
+		if !footerPattern.MatchString(strings.TrimSpace(line)) {
+			return false
+		}
+	}
+	return true
+}
+
+// unwrap strips surrounding whitespace, code fences and quotes from a model answer.
+func unwrap(content string) string {
+	text := strings.TrimSpace(content)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```")
+		// Drop the fence's language tag, e.g. ```json or ```text
+		if i := strings.Index(text, "\n"); i >= 0 && !strings.ContainsAny(text[:i], " {") {
+			text = text[i+1:]
+		}
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "```"))
+	}
+	for _, quote := range []string{`"`, "'", "`"} {
+		if len(text) >= 2 && strings.HasPrefix(text, quote) && strings.HasSuffix(text, quote) {
+			text = strings.TrimSpace(text[1 : len(text)-1])
+		}
+	}
+	return text
+}
+
+// normalize tidies the fields: lowercase type, trimmed text, no empty footers.
+func (m *CommitMessage) normalize() {
+	m.Type = strings.ToLower(strings.TrimSpace(m.Type))
+	m.Scope = strings.TrimSpace(m.Scope)
+	m.Subject = strings.TrimSpace(m.Subject)
+	m.Body = strings.TrimSpace(m.Body)
+
+	footers := m.Footers[:0]
+	for _, footer := range m.Footers {
+		if footer = strings.TrimSpace(footer); footer != "" {
+			footers = append(footers, footer)
+		}
+	}
+	m.Footers = footers
+}
+
+// Validate checks the fields against the commit style.
+func (m CommitMessage) Validate(style CommitStyle) error {
+	if m.Subject == "" {
+		return fmt.Errorf("subject cannot be empty")
+	}
+	if strings.Contains(m.Subject, "\n") {
+		return fmt.Errorf("subject must be a single line")
+	}
+	if _, known := canonicalType(style, m.Type); style != Empty && !known {
+		if m.Type == "" {
+			return fmt.Errorf("missing commit type")
+		}
+		return fmt.Errorf("unknown commit type %q", m.Type)
+	}
+	if strings.ContainsAny(m.Scope, "()\n") {
+		return fmt.Errorf("invalid scope %q", m.Scope)
+	}
+	for _, footer := range m.Footers {
+		if !footerPattern.MatchString(footer) {
+			return fmt.Errorf("invalid footer %q", footer)
+		}
+	}
+	return nil
+}
+
+// RetryPrompt extends the prompt with the reason the previous answer was
+// rejected, so that the model can correct it.
+func RetryPrompt(p string, rejected error) string {
+	return fmt.Sprintf("%s\n\nThe previous answer was rejected: %s. Answer again and follow the required format exactly.", p, rejected)
+}
+
+// Header returns the first line of the message in the given style.
+func (m CommitMessage) Header(style CommitStyle) string {
+	if style == Gitmoji && m.Type != "" {
+		return m.Type + " " + m.Subject
+	}
+	if style != Conventional || m.Type == "" {
+		return m.Subject
+	}
+
+	header := m.Type
+	if m.Scope != "" {
+		header += "(" + m.Scope + ")"
+	}
+	if m.Breaking {
+		header += "!"
+	}
+	return header + ": " + m.Subject
+}
+
+// Render formats the message in the given style.
+func (m CommitMessage) Render(style CommitStyle) string {
+	parts := []string{m.Header(style)}
+	if m.Body != "" {
+		parts = append(parts, m.Body)
+	}
+	if len(m.Footers) > 0 {
+		parts = append(parts, strings.Join(m.Footers, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,193 @@
+package prompt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCommitMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		style   CommitStyle
+		want    CommitMessage
+	}{
+		{
+			name:    "json",
+			content: `{"type":"feat","scope":"auth","subject":"add login","body":"Adds a login form.","breaking":false,"footers":["Refs: #12"]}`,
+			style:   Conventional,
+			want:    CommitMessage{Type: "feat", Scope: "auth", Subject: "add login", Body: "Adds a login form.", Footers: []string{"Refs: #12"}},
+		},
+		{
+			name:    "json in a code fence",
+			content: "```json\n{\"type\":\"FIX\",\"subject\":\" handle nil \",\"breaking\":true}\n```",
+			style:   Conventional,
+			want:    CommitMessage{Type: "fix", Subject: "handle nil", Breaking: true},
+		},
+		{
+			name:    "json multi-line subject moves into the body",
+			content: `{"type":"fix","subject":"handle nil\nwhen the config is missing","body":"Details."}`,
+			style:   Conventional,
+			want:    CommitMessage{Type: "fix", Subject: "handle nil", Body: "when the config is missing\n\nDetails."},
+		},
+		{
+			name:    "json bad scope is dropped",
+			content: `{"type":"fix","scope":"api(v2)","subject":"handle nil"}`,
+			style:   Conventional,
+			want:    CommitMessage{Type: "fix", Subject: "handle nil"},
+		},
+		{
+			name:    "json invalid footers are dropped",
+			content: `{"type":"fix","subject":"handle nil","footers":["Refs: #12","see the issue","", "BREAKING CHANGE: config moved"]}`,
+			style:   Conventional,
+			want:    CommitMessage{Type: "fix", Subject: "handle nil", Footers: []string{"Refs: #12", "BREAKING CHANGE: config moved"}},
+		},
+		{
+			name:    "json unknown type is kept",
+			content: `{"type":"feature","subject":"add login"}`,
+			style:   Conventional,
+			want:    CommitMessage{Type: "feature", Subject: "add login"},
+		},
+		{
+			name:    "json gitmoji code becomes the emoji",
+			content: `{"type":":sparkles:","subject":"add login"}`,
+			style:   Gitmoji,
+			want:    CommitMessage{Type: "✨", Subject: "add login"},
+		},
+		{
+			name:    "json type of a plain message is dropped",
+			content: `{"type":"feat","subject":"Add login"}`,
+			style:   Empty,
+			want:    CommitMessage{Subject: "Add login"},
+		},
+		{
+			name:    "text",
+			content: "feat(auth)!: add login\n\nAdds a login form.\n\nRefs: #12\nCloses: #13",
+			style:   Conventional,
+			want:    CommitMessage{Type: "feat", Scope: "auth", Subject: "add login", Body: "Adds a login form.", Breaking: true, Footers: []string{"Refs: #12", "Closes: #13"}},
+		},
+		{
+			name:    "quoted text",
+			content: `"fix: handle nil"`,
+			style:   Conventional,
+			want:    CommitMessage{Type: "fix", Subject: "handle nil"},
+		},
+		{
+			name:    "text uppercase type",
+			content: "Fix: handle nil",
+			style:   Conventional,
+			want:    CommitMessage{Type: "fix", Subject: "handle nil"},
+		},
+		{
+			name:    "text unknown type is kept",
+			content: "feature: add login",
+			style:   Conventional,
+			want:    CommitMessage{Type: "feature", Subject: "add login"},
+		},
+		{
+			name:    "text without a type",
+			content: "add login",
+			style:   Conventional,
+			want:    CommitMessage{Subject: "add login"},
+		},
+		{
+			name:    "text paragraph with a non-footer line stays in the body",
+			content: "fix: handle nil\n\nRefs: #12\nsee the issue",
+			style:   Conventional,
+			want:    CommitMessage{Type: "fix", Subject: "handle nil", Body: "Refs: #12\nsee the issue"},
+		},
+		{
+			name:    "text gitmoji",
+			content: "🐛 handle nil",
+			style:   Gitmoji,
+			want:    CommitMessage{Type: "🐛", Subject: "handle nil"},
+		},
+		{
+			name:    "text plain",
+			content: "fix: handle nil",
+			style:   Empty,
+			want:    CommitMessage{Subject: "fix: handle nil"},
+		},
+		{
+			name:    "empty answer",
+			content: "```\n```",
+			style:   Conventional,
+			want:    CommitMessage{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseCommitMessage(tt.content, tt.style)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCommitMessage() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     CommitMessage
+		style   CommitStyle
+		wantErr string
+	}{
+		{name: "valid", msg: CommitMessage{Type: "feat", Scope: "api", Subject: "add login", Footers: []string{"Refs: #1"}}, style: Conventional},
+		{name: "plain", msg: CommitMessage{Subject: "Add login"}, style: Empty},
+		{name: "gitmoji", msg: CommitMessage{Type: "✨", Subject: "add login"}, style: Gitmoji},
+		{name: "empty subject", msg: CommitMessage{Type: "feat"}, style: Conventional, wantErr: "subject cannot be empty"},
+		{name: "multi-line subject", msg: CommitMessage{Type: "feat", Subject: "a\nb"}, style: Conventional, wantErr: "subject must be a single line"},
+		{name: "missing type", msg: CommitMessage{Subject: "add login"}, style: Conventional, wantErr: "missing commit type"},
+		{name: "unknown type", msg: CommitMessage{Type: "feature", Subject: "add login"}, style: Conventional, wantErr: `unknown commit type "feature"`},
+		{name: "conventional type in gitmoji", msg: CommitMessage{Type: "feat", Subject: "add login"}, style: Gitmoji, wantErr: `unknown commit type "feat"`},
+		{name: "bad scope", msg: CommitMessage{Type: "feat", Scope: "a(b)", Subject: "add login"}, style: Conventional, wantErr: `invalid scope "a(b)"`},
+		{name: "bad footer", msg: CommitMessage{Type: "feat", Subject: "add login", Footers: []string{"see #1"}}, style: Conventional, wantErr: `invalid footer "see #1"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.msg.Validate(tt.style)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate() = %v", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Errorf("Validate() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	msg := CommitMessage{Type: "feat", Scope: "auth", Subject: "add login", Body: "Adds a login form.", Breaking: true, Footers: []string{"Refs: #12", "Closes: #13"}}
+	tests := []struct {
+		name  string
+		msg   CommitMessage
+		style CommitStyle
+		want  string
+	}{
+		{name: "conventional", msg: msg, style: Conventional, want: "feat(auth)!: add login\n\nAdds a login form.\n\nRefs: #12\nCloses: #13"},
+		{name: "conventional without scope", msg: CommitMessage{Type: "fix", Subject: "handle nil"}, style: Conventional, want: "fix: handle nil"},
+		{name: "gitmoji", msg: CommitMessage{Type: "🐛", Subject: "handle nil", Body: "Details."}, style: Gitmoji, want: "🐛 handle nil\n\nDetails."},
+		{name: "plain", msg: CommitMessage{Subject: "Handle nil", Footers: []string{"Refs: #1"}}, style: Empty, want: "Handle nil\n\nRefs: #1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.msg.Render(tt.style)
+			if got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+			// A rendered message parses back into the same fields
+			if parsed := ParseCommitMessage(got, tt.style); parsed.Render(tt.style) != got {
+				t.Errorf("round trip = %q, want %q", parsed.Render(tt.style), got)
+			}
+		})
+	}
+}
+
+func TestRetryPrompt(t *testing.T) {
+	msg := ParseCommitMessage(`{"type":"feature","subject":"add login"}`, Conventional)
+	got := RetryPrompt("Write a commit message.", msg.Validate(Conventional))
+	if !strings.HasPrefix(got, "Write a commit message.\n\n") || !strings.Contains(got, `rejected: unknown commit type "feature".`) {
+		t.Errorf("RetryPrompt() = %q", got)
+	}
+}
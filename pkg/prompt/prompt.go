@@ -3,6 +3,7 @@ package prompt
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 )
 
 // Locale defines the supported languages for commit messages.
@@ -29,6 +30,11 @@ func (c Locale) String() string {
 	return string(c)
 }
 
+// SupportedLocales lists every locale a prompt can be generated for.
+func SupportedLocales() []Locale {
+	return []Locale{EnUS, EnGB, FrFR, EsES, DeDE, ItIT, KoKR, JaJP, ZhCN, ZhTW, PtBR, RuRU, ArSA, HiIN}
+}
+
 // CommitType defines the type of commit messages.
 type CommitType string
 
@@ -50,6 +56,16 @@ func (c CommitType) String() string {
 	return string(c)
 }
 
+// CommitTypes returns every conventional commit type, sorted by name.
+func CommitTypes() []CommitType {
+	types := make([]CommitType, 0, len(commitTypeDescriptions))
+	for t := range commitTypeDescriptions {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
 // commitTypeDescriptions maps CommitType to its description.
 var commitTypeDescriptions = map[CommitType]string{
 	Build:    "Changes that affect the build system or external dependencies (e.g., gulp, broccoli, npm).",
@@ -71,12 +87,14 @@ type CommitStyle string
 const (
 	Empty        CommitStyle = ""
 	Conventional CommitStyle = "conventional"
+	Gitmoji      CommitStyle = "gitmoji"
 )
 
 // commitStyleFormats defines commit message formats as strings.
 var commitStyleFormats = map[CommitStyle]string{
 	Empty:        "<commit message>",
 	Conventional: "<type>(<optional scope>): <commit message starting with lowercase>",
+	Gitmoji:      "<gitmoji> <commit message starting with lowercase>",
 }
 
 // SpecifyCommitFormat returns the format specification for a given CommitStyle.
@@ -88,30 +106,73 @@ func SpecifyCommitFormat(style CommitStyle) (string, error) {
 	return fmt.Sprintf("The output response must be in format:\n%s", format), nil
 }
 
-// generateCommitTypeDescriptions converts the commitTypeDescriptions map to a JSON string
+// generateCommitTypeDescriptions converts the commit types to a JSON string
 // and formats it for inclusion in the prompt.
-func generateCommitTypeDescriptions() (string, error) {
+func generateCommitTypeDescriptions(style CommitStyle, types []TypeDescription) (string, error) {
 	descriptionMap := make(map[string]string)
-	for key, value := range commitTypeDescriptions {
-		descriptionMap[string(key)] = value
+	for _, t := range types {
+		descriptionMap[string(t.Name)] = t.Description
 	}
 
 	jsonDescriptions, err := json.MarshalIndent(descriptionMap, "", "  ")
 	if err != nil {
 		return "", fmt.Errorf("failed to generate commit descriptions: %v", err)
 	}
-	return fmt.Sprintf(
-		"Choose a type from the type-to-description JSON below that best describes the git diff:\n%s",
-		string(jsonDescriptions),
-	), nil
+
+	intro := "Choose a type from the type-to-description JSON below that best describes the git diff:\n%s"
+	if style == Gitmoji {
+		intro = "Choose a gitmoji from the gitmoji-to-description JSON below that best describes the git diff:\n%s"
+	}
+	return fmt.Sprintf(intro, string(jsonDescriptions)), nil
+}
+
+// describeStyle fills in the commit types, their descriptions and the output
+// format of the given style.
+func describeStyle(style CommitStyle, config *Config) error {
+	// Generate commit type descriptions if the commit style has types.
+	if style == Conventional || style == Gitmoji {
+		config.Types = styleTypes(style, config.AllowedTypes)
+		if style == Conventional && len(config.PathTypes) > 0 {
+			config.Types = styleTypes(style, config.PathTypes)
+		} else {
+			config.PathTypes = nil
+		}
+		descriptions, err := generateCommitTypeDescriptions(style, config.Types)
+		if err != nil {
+			return err
+		}
+		config.CommitDescriptions = descriptions
+	}
+
+	commitFormat, err := SpecifyCommitFormat(style)
+	if err != nil {
+		return err
+	}
+	if config.OutputFormat == JSON || config.OutputFormat == JSONSchema {
+		commitFormat = specifyJSONFormat(style)
+	}
+	config.CommitFormat = commitFormat
+
+	return nil
 }
 
 // Config holds configuration for generating a commit message prompt.
 type Config struct {
-	Locale             Locale // The language of the commit message.
-	MaxLength          int    // Maximum allowed character length for the message.
-	CommitDescriptions string // Description of available commit types.
-	CommitFormat       string // Commit message format (e.g., "<type>(<scope>): <message>").
+	Locale             Locale            // The language of the commit message.
+	MaxLength          int               // Maximum allowed character length for the message.
+	CommitDescriptions string            // Description of available commit types.
+	CommitFormat       string            // Commit message format (e.g., "<type>(<scope>): <message>").
+	Issue              string            // Issue tracker key the change belongs to, if any.
+	OutputFormat       OutputFormat      // How the model returns the message (text or JSON).
+	Types              []TypeDescription // Commit types to choose from; empty for plain messages.
+	AllowedTypes       []string          // Restricts the commit types offered; empty offers all of them.
+	PathTypes          []string          // Commit types implied by the paths of the changed files.
+	Scopes             []string          // Scopes used by the project, most common first.
+	Files              []string          // Paths of the changed files.
+	Branch             string            // Name of the current branch.
+	RecentCommits      []string          // Subjects of the latest commits, newest first.
+	Examples           []string          // Past commit messages whose style should be matched.
+	TemplateDirs       []string          // Directories searched for prompt overrides, highest priority first.
 }
 
 // Option defines a functional option for configuring the prompt generation.
@@ -131,6 +192,78 @@ func WithMaxLength(maxLength int) Option {
 	}
 }
 
+// WithIssue sets the issue tracker key in the configuration.
+func WithIssue(key string) Option {
+	return func(cfg *Config) {
+		cfg.Issue = key
+	}
+}
+
+// WithOutputFormat sets how the model should return the commit message.
+func WithOutputFormat(format OutputFormat) Option {
+	return func(cfg *Config) {
+		cfg.OutputFormat = format
+	}
+}
+
+// WithCommitTypes restricts the commit types, or gitmojis, the model may choose from.
+func WithCommitTypes(types []string) Option {
+	return func(cfg *Config) {
+		cfg.AllowedTypes = types
+	}
+}
+
+// WithPathTypes restricts the commit types to those implied by the paths of
+// the changed files, and tells the model why.
+func WithPathTypes(types []string) Option {
+	return func(cfg *Config) {
+		cfg.PathTypes = types
+	}
+}
+
+// WithScopes sets the scopes the model should prefer.
+func WithScopes(scopes []string) Option {
+	return func(cfg *Config) {
+		cfg.Scopes = scopes
+	}
+}
+
+// WithFiles sets the paths of the changed files in the configuration.
+func WithFiles(files []string) Option {
+	return func(cfg *Config) {
+		cfg.Files = files
+	}
+}
+
+// WithBranch sets the current branch name in the configuration.
+func WithBranch(branch string) Option {
+	return func(cfg *Config) {
+		cfg.Branch = branch
+	}
+}
+
+// WithRecentCommits sets the subjects of the latest commits in the configuration.
+func WithRecentCommits(subjects []string) Option {
+	return func(cfg *Config) {
+		cfg.RecentCommits = subjects
+	}
+}
+
+// WithExamples sets past commit messages whose style the model should match.
+func WithExamples(messages []string) Option {
+	return func(cfg *Config) {
+		cfg.Examples = messages
+	}
+}
+
+// WithTemplateDirs sets the directories searched for <name>.tmpl prompt
+// overrides, highest priority first. The built-in prompts are used otherwise.
+func WithTemplateDirs(dirs ...string) Option {
+	return func(cfg *Config) {
+		cfg.TemplateDirs = dirs
+	}
+}
+
 // GenerateCommitPrompt generates a concise prompt for creating git commit messages.
 func GenerateCommitPrompt(style CommitStyle, opts ...Option) (string, error) {
 	// Default configuration
@@ -144,25 +277,10 @@ func GenerateCommitPrompt(style CommitStyle, opts ...Option) (string, error) {
 		opt(config)
 	}
 
-	commitDescriptions := ""
-
-	// Generate commit type descriptions if the commit style is conventional.
-	if style == Conventional {
-		var err error
-		commitDescriptions, err = generateCommitTypeDescriptions()
-		if err != nil {
-			return "", err
-		}
-	}
-
-	commitFormat, err := SpecifyCommitFormat(style)
-	if err != nil {
+	if err := describeStyle(style, config); err != nil {
 		return "", err
 	}
 
-	config.CommitDescriptions = commitDescriptions
-	config.CommitFormat = commitFormat
-
 	// Build the prompt using the configuration.
 	return buildCommitPrompt(config)
 }
@@ -180,21 +298,7 @@ func buildCommitPrompt(config *Config) (string, error) {
 		return "", fmt.Errorf("commitFormat cannot be empty")
 	}
 
-	// Construct the prompt
-	return fmt.Sprintf(
-		`Write a concise and relevant git commit message for the given code diff:
-Language: %s
-Maximum length: %d characters.
-Focus: Only include details about the code changes. Avoid unnecessary information such as translations or extra explanations.
-Format: Use the specified commit message format:
-%s
-%s
-`,
-		config.Locale.String(),
-		config.MaxLength,
-		config.CommitDescriptions,
-		config.CommitFormat,
-	), nil
+	return renderTemplate("commit", config)
 }
 
 // GenerateBranchNamePrompt generates a concise prompt for creating Git branch names.
@@ -224,13 +328,40 @@ func GenerateBranchNamePrompt(opts ...Option) (string, error) {
 
 // buildBranchNamePrompt constructs the final prompt string based on the given configuration.
 func buildBranchNamePrompt(config *Config) (string, error) {
-	return fmt.Sprintf(
-		`Generate a concise and descriptive Git branch name for the given context:
-Language: %s
-Maximum length: %d characters.
-Focus: Use hyphens to separate words. The branch name should reflect the changes being made or the feature being implemented.
-`,
-		config.Locale.String(),
-		config.MaxLength,
-	), nil
+	return renderTemplate("branch", config)
+}
+
+// GenerateSquashPrompt generates a prompt for summarising a whole branch into a single commit message.
+func GenerateSquashPrompt(style CommitStyle, opts ...Option) (string, error) {
+	// Default configuration
+	config := &Config{
+		Locale:    EnUS, // Default to en-US
+		MaxLength: 72,   // Default max length
+	}
+
+	// Apply functional options
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	// Squash messages are always requested as text
+	config.OutputFormat = Text
+	if err := describeStyle(style, config); err != nil {
+		return "", err
+	}
+
+	// Validate configuration
+	if config.Locale.String() == "" {
+		return "", fmt.Errorf("locale cannot be empty")
+	}
+	if config.MaxLength <= 0 {
+		return "", fmt.Errorf("maxLength must be greater than 0")
+	}
+
+	return buildSquashPrompt(config)
+}
+
+// buildSquashPrompt constructs the squash prompt string based on the given configuration.
+func buildSquashPrompt(config *Config) (string, error) {
+	return renderTemplate("squash", config)
 }
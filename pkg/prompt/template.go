@@ -0,0 +1,124 @@
+package prompt
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// builtinTemplates holds the default prompts, one <name>.tmpl file per prompt.
+//
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// BuiltinSource is the source reported for prompts that are not overridden.
+const BuiltinSource = "built-in"
+
+// TemplateNames lists the prompts that can be overridden.
+func TemplateNames() []string {
+	return []string{"branch", "commit", "squash"}
+}
+
+// TypeDescription is a commit type offered to the model.
+type TypeDescription struct {
+	Name        CommitType
+	Description string
+}
+
+// styleTypes lists the commit types, or gitmojis, of a style in a stable
+// order, keeping only the allowed ones when any are given.
+func styleTypes(style CommitStyle, allowed []string) []TypeDescription {
+	var types []TypeDescription
+	if style == Gitmoji {
+		for _, g := range gitmojis {
+			types = append(types, TypeDescription{Name: CommitType(g.Emoji), Description: g.Description})
+		}
+	} else {
+		for name, description := range commitTypeDescriptions {
+			types = append(types, TypeDescription{Name: name, Description: description})
+		}
+		sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	}
+
+	if len(allowed) == 0 {
+		return types
+	}
+
+	kept := types[:0]
+	for _, t := range types {
+		for _, name := range allowed {
+			if g, ok := LookupGitmoji(name); (ok && g.Emoji == string(t.Name)) || name == string(t.Name) {
+				kept = append(kept, t)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// templateFuncs are available to every prompt template.
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// TemplateSource returns where the named prompt is loaded from: the path of the
+// first <name>.tmpl found in dirs, or BuiltinSource.
+func TemplateSource(name string, dirs []string) (string, error) {
+	_, source, err := loadTemplate(name, dirs)
+	return source, err
+}
+
+// loadTemplate parses the named prompt from the first directory that has an
+// override, falling back to the built-in template.
+func loadTemplate(name string, dirs []string) (*template.Template, string, error) {
+	file := name + ".tmpl"
+
+	for _, dir := range dirs {
+		path := filepath.Join(dir, file)
+		data, err := os.ReadFile(filepath.Clean(path))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read prompt template %s: %w", path, err)
+		}
+
+		tmpl, err := template.New(file).Funcs(templateFuncs).Parse(string(data))
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid prompt template %s: %w", path, err)
+		}
+		return tmpl, path, nil
+	}
+
+	tmpl, err := template.New(file).Funcs(templateFuncs).ParseFS(builtinTemplates, "templates/"+file)
+	if err != nil {
+		return nil, "", fmt.Errorf("unknown prompt %q", name)
+	}
+	return tmpl, BuiltinSource, nil
+}
+
+// renderTemplate executes the named prompt with the configuration as its data.
+// The API refuses JSON answers to prompts that do not ask for JSON, so the
+// format instructions are appended when a JSON prompt leaves out {{.CommitFormat}}.
+func renderTemplate(name string, config *Config) (string, error) {
+	tmpl, source, err := loadTemplate(name, config.TemplateDirs)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, config); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %w", source, err)
+	}
+
+	p := out.String()
+	if (config.OutputFormat == JSON || config.OutputFormat == JSONSchema) && !strings.Contains(p, config.CommitFormat) {
+		p = strings.TrimRight(p, "\n") + "\n" + config.CommitFormat + "\n"
+	}
+	return p, nil
+}
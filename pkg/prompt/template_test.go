@@ -0,0 +1,94 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// templateDir returns a directory holding the given prompt templates.
+func templateDir(t *testing.T, templates map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range templates {
+		if err := os.WriteFile(filepath.Join(dir, name+".tmpl"), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCommitFormatInstructions(t *testing.T) {
+	withFormat := "Describe the diff in {{.Locale}}.\n{{.CommitFormat}}\n"
+	withoutFormat := "Describe the diff in {{.Locale}}.\n"
+
+	tests := []struct {
+		name     string
+		template string
+		format   OutputFormat
+		wantJSON int // how often the prompt describes the JSON object
+		wantText int // how often the prompt describes the text format
+	}{
+		{name: "builtin json", format: JSON, wantJSON: 1},
+		{name: "builtin text", format: Text, wantText: 1},
+		{name: "custom json with format", template: withFormat, format: JSON, wantJSON: 1},
+		{name: "custom json without format", template: withoutFormat, format: JSON, wantJSON: 1},
+		{name: "custom json schema without format", template: withoutFormat, format: JSONSchema, wantJSON: 1},
+		{name: "custom text without format", template: withoutFormat, format: Text},
+		{name: "custom text with format", template: withFormat, format: Text, wantText: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.template != "" {
+				opts = append(opts, WithTemplateDirs(templateDir(t, map[string]string{"commit": tt.template})))
+			}
+			p, err := GenerateCommitPrompt(Conventional, append(opts, WithOutputFormat(tt.format))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.Count(p, "must be a JSON object"); got != tt.wantJSON {
+				t.Errorf("prompt describes the JSON object %d times:\n%s", got, p)
+			}
+			if got := strings.Count(p, "must be in format"); got != tt.wantText {
+				t.Errorf("prompt describes the text format %d times:\n%s", got, p)
+			}
+			if tt.template != "" && !strings.HasPrefix(p, "Describe the diff in en-US.\n") {
+				t.Errorf("prompt does not start with the template:\n%s", p)
+			}
+		})
+	}
+}
+
+func TestTemplateSource(t *testing.T) {
+	repo := templateDir(t, map[string]string{"commit": "repo {{.CommitFormat}}"})
+	user := templateDir(t, map[string]string{"commit": "user {{.CommitFormat}}", "branch": "user branch"})
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "commit", want: filepath.Join(repo, "commit.tmpl")},
+		{name: "branch", want: filepath.Join(user, "branch.tmpl")},
+		{name: "squash", want: BuiltinSource},
+	}
+	for _, tt := range tests {
+		got, err := TemplateSource(tt.name, []string{repo, user})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("TemplateSource(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := TemplateSource("unknown", nil); err == nil {
+		t.Error("TemplateSource accepted an unknown prompt")
+	}
+	broken := templateDir(t, map[string]string{"commit": "{{.Locale"})
+	if _, err := TemplateSource("commit", []string{broken}); err == nil {
+		t.Error("TemplateSource accepted an invalid template")
+	}
+}
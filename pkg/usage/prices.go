@@ -0,0 +1,96 @@
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Price is the cost of a model in USD per million tokens.
+type Price struct {
+	Input  float64
+	Output float64
+}
+
+// DefaultPrices lists list prices of common OpenAI models in USD per million tokens.
+var DefaultPrices = Prices{
+	"gpt-3.5-turbo": {Input: 0.50, Output: 1.50},
+	"gpt-35-turbo":  {Input: 0.50, Output: 1.50}, // Azure's name for gpt-3.5-turbo
+	"gpt-4":         {Input: 30.00, Output: 60.00},
+	"gpt-4-turbo":   {Input: 10.00, Output: 30.00},
+	"gpt-4o":        {Input: 2.50, Output: 10.00},
+	"gpt-4o-mini":   {Input: 0.15, Output: 0.60},
+	"gpt-4.1":       {Input: 2.00, Output: 8.00},
+	"gpt-4.1-mini":  {Input: 0.40, Output: 1.60},
+	"gpt-4.1-nano":  {Input: 0.10, Output: 0.40},
+}
+
+// Prices maps model names to their price. Dated model versions such as
+// gpt-4o-2024-08-06 use the price of the longest matching name.
+type Prices map[string]Price
+
+// ParsePrices parses overrides in the form "model=input/output,...", in USD
+// per million tokens, and merges them over the default prices.
+func ParsePrices(value string) (Prices, error) {
+	prices := make(Prices, len(DefaultPrices))
+	for model, price := range DefaultPrices {
+		prices[model] = price
+	}
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		model, costs, ok := strings.Cut(item, "=")
+		input, output, ok2 := strings.Cut(costs, "/")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid price %q, expected model=input/output", item)
+		}
+
+		in, err := strconv.ParseFloat(strings.TrimSpace(input), 64)
+		if err != nil || in < 0 {
+			return nil, fmt.Errorf("invalid input price in %q", item)
+		}
+		out, err := strconv.ParseFloat(strings.TrimSpace(output), 64)
+		if err != nil || out < 0 {
+			return nil, fmt.Errorf("invalid output price in %q", item)
+		}
+
+		prices[strings.TrimSpace(model)] = Price{Input: in, Output: out}
+	}
+
+	return prices, nil
+}
+
+// Lookup returns the price of a model, reporting whether one is known.
+func (p Prices) Lookup(model string) (Price, bool) {
+	if price, ok := p[model]; ok {
+		return price, true
+	}
+
+	// Longest prefix first, so gpt-4o-mini wins over gpt-4o and gpt-4
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+
+	for _, name := range names {
+		if strings.HasPrefix(model, name+"-") {
+			return p[name], true
+		}
+	}
+	return Price{}, false
+}
+
+// Cost estimates the cost in USD of a call.
+func (p Prices) Cost(model string, promptTokens, completionTokens int) float64 {
+	price, ok := p.Lookup(model)
+	if !ok {
+		return 0
+	}
+	return (float64(promptTokens)*price.Input + float64(completionTokens)*price.Output) / 1e6
+}
@@ -0,0 +1,93 @@
+package usage
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParsePrices(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		model   string
+		want    Price
+		wantErr bool
+	}{
+		{name: "defaults", value: "", model: "gpt-4o", want: DefaultPrices["gpt-4o"]},
+		{name: "override", value: "gpt-4o=1/2", model: "gpt-4o", want: Price{Input: 1, Output: 2}},
+		{name: "new model with spaces", value: " my-model = 0.5 / 1.25 , gpt-4o=1/2", model: "my-model", want: Price{Input: 0.5, Output: 1.25}},
+		{name: "free model", value: "local=0/0", model: "local", want: Price{}},
+		{name: "missing output", value: "gpt-4o=1", wantErr: true},
+		{name: "missing model", value: "1/2", wantErr: true},
+		{name: "negative price", value: "gpt-4o=-1/2", wantErr: true},
+		{name: "not a number", value: "gpt-4o=1/x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prices, err := ParsePrices(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParsePrices(%q) succeeded, want an error", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := prices[tt.model]; got != tt.want {
+				t.Errorf("price of %s = %+v, want %+v", tt.model, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePricesKeepsDefaults(t *testing.T) {
+	before := DefaultPrices["gpt-4o"]
+	prices, err := ParsePrices("gpt-4o=1/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if DefaultPrices["gpt-4o"] != before {
+		t.Error("ParsePrices modified DefaultPrices")
+	}
+	if prices["gpt-4o-mini"] != DefaultPrices["gpt-4o-mini"] {
+		t.Error("models without an override lost their default price")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		model  string
+		want   string // name whose price applies, or "" if none
+		wantOK bool
+	}{
+		{"gpt-4o", "gpt-4o", true},
+		{"gpt-4o-2024-08-06", "gpt-4o", true},
+		{"gpt-4o-mini-2024-07-18", "gpt-4o-mini", true},
+		{"gpt-4.1-nano", "gpt-4.1-nano", true},
+		{"gpt-4-0613", "gpt-4", true},
+		{"gpt-4oo", "", false},
+		{"unknown", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			got, ok := DefaultPrices.Lookup(tt.model)
+			if ok != tt.wantOK {
+				t.Fatalf("Lookup(%q) ok = %v, want %v", tt.model, ok, tt.wantOK)
+			}
+			if ok && got != DefaultPrices[tt.want] {
+				t.Errorf("Lookup(%q) = %+v, want the price of %s", tt.model, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCost(t *testing.T) {
+	prices := Prices{"m": {Input: 2, Output: 10}}
+	if got := prices.Cost("m-2025", 1_000_000, 500_000); math.Abs(got-7) > 1e-9 {
+		t.Errorf("Cost = %v, want 7", got)
+	}
+	if got := prices.Cost("other", 1_000_000, 1_000_000); got != 0 {
+		t.Errorf("Cost of an unknown model = %v, want 0", got)
+	}
+}
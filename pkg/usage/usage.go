@@ -0,0 +1,162 @@
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Record is a single model call.
+type Record struct {
+	Time             time.Time `json:"time"`
+	Command          string    `json:"command"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	CostUSD          float64   `json:"cost_usd"`
+}
+
+// DefaultPath returns the usage ledger, ~/.combo/usage.jsonl.
+func DefaultPath() (string, error) {
+	dir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(dir, ".combo", "usage.jsonl"), nil
+}
+
+// Tracker records the token usage of one command and enforces the daily budget.
+type Tracker struct {
+	path    string
+	command string
+	prices  Prices
+	budget  float64
+	now     func() time.Time
+}
+
+// NewTracker returns a tracker appending to the ledger at path.
+// A non-positive budget means no limit.
+func NewTracker(path, command string, prices Prices, budget float64) *Tracker {
+	return &Tracker{path: path, command: command, prices: prices, budget: budget, now: time.Now}
+}
+
+// Allow refuses a call once today's spending has reached the daily budget,
+// and any call to a model without a known price, whose cost would go uncounted.
+func (t *Tracker) Allow(model string) error {
+	if t.budget <= 0 {
+		return nil
+	}
+	if _, ok := t.prices.Lookup(model); !ok {
+		return fmt.Errorf("no price known for model %s, so daily_budget_usd cannot be enforced; set one with model_prices, e.g. %s=<input>/<output> in USD per million tokens", model, model)
+	}
+
+	records, err := Load(t.path)
+	if err != nil {
+		return err
+	}
+
+	today := t.now().Format(time.DateOnly)
+	var spent float64
+	for _, r := range records {
+		if r.Time.Local().Format(time.DateOnly) == today {
+			spent += r.CostUSD
+		}
+	}
+
+	if spent >= t.budget {
+		return fmt.Errorf("daily budget of $%.2f reached ($%.4f spent today); raise daily_budget_usd or try again tomorrow", t.budget, spent)
+	}
+	return nil
+}
+
+// Record appends a call to the ledger with its estimated cost.
+func (t *Tracker) Record(model string, promptTokens, completionTokens int) error {
+	data, err := json.Marshal(Record{
+		Time:             t.now(),
+		Command:          t.command,
+		Model:            model,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		CostUSD:          t.prices.Cost(model, promptTokens, completionTokens),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode usage record: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create usage directory: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Clean(t.path), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open usage ledger: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write usage record: %w", err)
+	}
+	return nil
+}
+
+// Load reads every record from the ledger at path.
+func Load(path string) ([]Record, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open usage ledger: %w", err)
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("invalid usage record in %s: %w", path, err)
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read usage ledger: %w", err)
+	}
+
+	return records, nil
+}
+
+// Totals sums the usage of a group of records.
+type Totals struct {
+	Calls            int
+	PromptTokens     int
+	CompletionTokens int
+	CostUSD          float64
+}
+
+// Add includes a record in the totals.
+func (t *Totals) Add(r Record) {
+	t.Calls++
+	t.PromptTokens += r.PromptTokens
+	t.CompletionTokens += r.CompletionTokens
+	t.CostUSD += r.CostUSD
+}
+
+// GroupBy sums the records by the key returned for each of them.
+func GroupBy(records []Record, key func(Record) string) map[string]*Totals {
+	groups := make(map[string]*Totals)
+	for _, r := range records {
+		k := key(r)
+		if groups[k] == nil {
+			groups[k] = &Totals{}
+		}
+		groups[k].Add(r)
+	}
+	return groups
+}
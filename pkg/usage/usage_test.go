@@ -0,0 +1,122 @@
+package usage
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestTracker returns a tracker with a ledger in a temporary directory,
+// whose clock reads from *now.
+func newTestTracker(t *testing.T, budget float64, now *time.Time) *Tracker {
+	tracker := NewTracker(filepath.Join(t.TempDir(), "usage.jsonl"), "commit", Prices{"m": {Input: 1, Output: 1}}, budget)
+	tracker.now = func() time.Time { return *now }
+	return tracker
+}
+
+func TestTrackerBudgetRollsOverAtMidnight(t *testing.T) {
+	now := time.Date(2026, 3, 1, 23, 50, 0, 0, time.Local)
+	tracker := newTestTracker(t, 1, &now)
+
+	if err := tracker.Allow("m"); err != nil {
+		t.Fatalf("Allow() with nothing spent: %v", err)
+	}
+	// $1 of a $1 budget
+	if err := tracker.Record("m", 600_000, 400_000); err != nil {
+		t.Fatal(err)
+	}
+	err := tracker.Allow("m")
+	if err == nil || !strings.Contains(err.Error(), "daily budget of $1.00 reached") {
+		t.Fatalf("Allow() after spending the budget = %v, want the budget error", err)
+	}
+
+	// Still the same day a minute before midnight
+	now = time.Date(2026, 3, 1, 23, 59, 0, 0, time.Local)
+	if err := tracker.Allow("m"); err == nil {
+		t.Error("Allow() before midnight succeeded")
+	}
+
+	// The next day starts with a fresh budget
+	now = time.Date(2026, 3, 2, 0, 0, 1, 0, time.Local)
+	if err := tracker.Allow("m"); err != nil {
+		t.Errorf("Allow() after midnight: %v", err)
+	}
+}
+
+func TestTrackerBelowBudget(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	tracker := newTestTracker(t, 1, &now)
+
+	if err := tracker.Record("m", 300_000, 200_000); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.Allow("m"); err != nil {
+		t.Errorf("Allow() at half the budget: %v", err)
+	}
+}
+
+func TestTrackerWithoutBudget(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	tracker := newTestTracker(t, 0, &now)
+
+	if err := tracker.Record("m", 10_000_000, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.Allow("m"); err != nil {
+		t.Errorf("Allow() without a budget: %v", err)
+	}
+}
+
+func TestTrackerRefusesUnpricedModelWithBudget(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+
+	err := newTestTracker(t, 1, &now).Allow("unknown")
+	if err == nil || !strings.Contains(err.Error(), "model_prices") {
+		t.Errorf("Allow() of an unpriced model = %v, want a pointer to model_prices", err)
+	}
+	if err := newTestTracker(t, 0, &now).Allow("unknown"); err != nil {
+		t.Errorf("Allow() of an unpriced model without a budget: %v", err)
+	}
+}
+
+func TestLedger(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(t, 0, &now)
+
+	if err := tracker.Record("m-2025", 1000, 500); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.Record("unknown", 1000, 500); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := Load(tracker.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	if r := records[0]; r.Command != "commit" || r.Model != "m-2025" || r.PromptTokens != 1000 || r.CompletionTokens != 500 || !r.Time.Equal(now) {
+		t.Errorf("unexpected record %+v", r)
+	}
+	if got := records[0].CostUSD; got != 0.0015 {
+		t.Errorf("cost = %v, want 0.0015", got)
+	}
+	if got := records[1].CostUSD; got != 0 {
+		t.Errorf("cost of an unknown model = %v, want 0", got)
+	}
+
+	groups := GroupBy(records, func(r Record) string { return r.Command })
+	if totals := groups["commit"]; totals.Calls != 2 || totals.PromptTokens != 2000 {
+		t.Errorf("totals = %+v", totals)
+	}
+}
+
+func TestLoadMissingLedger(t *testing.T) {
+	records, err := Load(filepath.Join(t.TempDir(), "missing.jsonl"))
+	if err != nil || records != nil {
+		t.Errorf("Load() of a missing ledger = %v, %v; want no records", records, err)
+	}
+}